@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+func (c *Client) register() (uint32, chan *protocol.Package) {
+	c.callMutex.Lock()
+	defer c.callMutex.Unlock()
+	c.seq++
+	if c.seq == 0 {
+		c.seq++
+	}
+	ch := make(chan *protocol.Package, 1)
+	c.pending[c.seq] = ch
+	return c.seq, ch
+}
+
+func (c *Client) unregister(request uint32) {
+	c.callMutex.Lock()
+	defer c.callMutex.Unlock()
+	delete(c.pending, request)
+}
+
+//Call block until the reply arrive, ctx done, connection lost or client closed
+//Call is never buffered, it return ErrDisconnected while reconnecting
+//server error return as *protocol.S2CError
+func (c *Client) Call(ctx context.Context, cmd protocol.C2SCmd, req, resp proto.Message) error {
+	request, ch := c.register()
+	defer c.unregister(request)
+	c.writeMutex.Lock()
+	chLost, err := c.write(cmd, request, req)
+	c.writeMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	select {
+	case pkg := <-ch:
+		switch protocol.S2CCmd(pkg.Serial) {
+		case protocol.S2CCmd_Reply:
+			return proto.Unmarshal(pkg.Buff, resp)
+		case protocol.S2CCmd_Error:
+			var e protocol.S2CError
+			if err := proto.Unmarshal(pkg.Buff, &e); err != nil {
+				return err
+			}
+			return &e
+		default:
+			return fmt.Errorf("protocol(%d) is not a reply", pkg.Serial)
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-chLost:
+		return ErrDisconnected
+	case <-c.done:
+		return c.err
+	}
+}
+
+//reply deliver the reply to Call, return false if nobody wait for it
+func (c *Client) reply(pkg *protocol.Package) bool {
+	c.callMutex.Lock()
+	defer c.callMutex.Unlock()
+	ch, ok := c.pending[pkg.Request]
+	if !ok {
+		return false
+	}
+	delete(c.pending, pkg.Request)
+	ch <- pkg
+	return true
+}
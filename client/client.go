@@ -0,0 +1,501 @@
+//Package client connect chat server, several Client can run in one process
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+var (
+	//ErrClosed client has been closed
+	ErrClosed = errors.New("client closed")
+	//ErrDisconnected connection lost, reconnecting
+	ErrDisconnected = errors.New("client disconnected")
+	//ErrBufferFull too many msg sent while reconnecting
+	ErrBufferFull = errors.New("client send buffer full")
+	//ErrHeartbeatTimeout nothing read for Options.HeartbeatMisses heartbeats, the link is dead
+	ErrHeartbeatTimeout = errors.New("client heartbeat timeout")
+)
+
+//HandleFunc dispatch one package from server, cmd is the protocol id
+type HandleFunc func(cmd int32, msg []byte) error
+
+//Middleware wrap every dispatch
+//return without calling next to short-circuit, or post-process the result of next
+type Middleware func(next HandleFunc) HandleFunc
+
+//DefaultHeartbeat ping interval, a default server evict a silent client after 30s
+const DefaultHeartbeat = 10 * time.Second
+
+//DefaultHeartbeatMisses pongs missed in a row before the connection is treated as lost
+const DefaultHeartbeatMisses = 3
+
+//DefaultFlushInterval between buffered msg sent after reconnect, a default server allow 5 chats a second
+const DefaultFlushInterval = 200 * time.Millisecond
+
+//Options of Dial, zero value is plain tcp with DefaultHeartbeat
+type Options struct {
+	//TLS connect with tls if not nil
+	TLS *tls.Config
+	//MaxFrameSize max package body size, 0 is protocol.MaxFrameSize
+	MaxFrameSize int
+	//Heartbeat ping interval, 0 is DefaultHeartbeat, negative disable
+	//the server stop a player nothing read for 3 heartbeats of its own, 30s by default
+	Heartbeat time.Duration
+	//HeartbeatMisses connection is lost if nothing, not even a pong, is read for so many heartbeats
+	//0 is DefaultHeartbeatMisses, a silently dead link is found without waiting for tcp to give up
+	HeartbeatMisses int
+	//RetryInterval retry dial until ctx done, 0 dial only once
+	RetryInterval time.Duration
+	//Reconnect after connection lost, with jittered exponential backoff from MinBackoff to MaxBackoff
+	Reconnect  bool
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	//SendBuffer msg sent while reconnecting are sent after OnConnect, 0 Send fail while reconnecting
+	SendBuffer int
+	//FlushInterval between buffered msg, so that they are not throttled by the server
+	//0 is DefaultFlushInterval, negative send them at once
+	FlushInterval time.Duration
+	//OnConnect called every time connected, the first time before Dial return
+	//Call can be used in it to login, buffered msg is sent after it return
+	OnConnect func(c *Client)
+	//OnDisconnect called with the reason every time connection lost, and after Close
+	OnDisconnect func(c *Client, err error)
+}
+
+//Client connection to server, reconnect if Options.Reconnect
+type Client struct {
+	opts Options
+	addr string
+	rand *rand.Rand
+
+	mutex       sync.RWMutex
+	handles     map[int32]func([]byte)
+	middlewares []Middleware
+	chain       HandleFunc
+
+	//writeMutex guard conn, enc, chLost, ready and buffer
+	writeMutex sync.Mutex
+	conn       net.Conn
+	enc        *protocol.Encoder
+	chLost     chan struct{}
+	ready      bool
+	buffer     []message
+
+	callMutex sync.Mutex
+	seq       uint32
+	pending   map[uint32]chan *protocol.Package
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+//message buffered while reconnecting
+type message struct {
+	cmd protocol.C2SCmd
+	msg proto.Message
+}
+
+//dial addr, unix socket as unix:/path
+func dial(ctx context.Context, addr string, config *tls.Config) (net.Conn, error) {
+	network := "tcp"
+	if strings.HasPrefix(addr, "unix:") {
+		network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil || config == nil {
+		return conn, err
+	}
+	tlsConn := tls.Client(conn, config)
+	if deadline, ok := ctx.Deadline(); ok {
+		tlsConn.SetDeadline(deadline)
+		defer tlsConn.SetDeadline(time.Time{})
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+//New client not connected yet, opts can be nil
+//Handle and Use before Dial so that pushes sent at login are not missed
+func New(opts *Options) *Client {
+	c := &Client{
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		handles: make(map[int32]func([]byte)),
+		pending: make(map[uint32]chan *protocol.Package),
+		done:    make(chan struct{}),
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.MaxFrameSize <= 0 {
+		c.opts.MaxFrameSize = protocol.MaxFrameSize
+	}
+	if c.opts.Heartbeat == 0 {
+		c.opts.Heartbeat = DefaultHeartbeat
+	}
+	if c.opts.FlushInterval == 0 {
+		c.opts.FlushInterval = DefaultFlushInterval
+	}
+	if c.opts.HeartbeatMisses <= 0 {
+		c.opts.HeartbeatMisses = DefaultHeartbeatMisses
+	}
+	if c.opts.MinBackoff <= 0 {
+		c.opts.MinBackoff = 500 * time.Millisecond
+	}
+	if c.opts.MaxBackoff < c.opts.MinBackoff {
+		c.opts.MaxBackoff = 30 * time.Second
+	}
+	c.chain = c.route
+	c.HandleMessage(protocol.S2CCmd_Pong, &protocol.S2CPong{}, showPong)
+	return c
+}
+
+//Dial connect addr, unix socket as unix:/path, OnConnect is called before return
+//call once, a Client is not reused after Close
+func (c *Client) Dial(ctx context.Context, addr string) error {
+	c.addr = addr
+	var conn net.Conn
+	for {
+		var err error
+		if conn, err = dial(ctx, addr, c.opts.TLS); err == nil {
+			break
+		}
+		if c.opts.RetryInterval <= 0 {
+			return err
+		}
+		log.Printf("connect %s: %v\n", addr, err)
+		select {
+		case <-time.After(c.opts.RetryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.connect(conn)
+	return nil
+}
+
+//Dial New and Dial, handles added after it return miss pushes sent before
+//use New when the server push at login
+func Dial(ctx context.Context, addr string, opts *Options) (*Client, error) {
+	c := New(opts)
+	if err := c.Dial(ctx, addr); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//connect start using conn, buffered msg is sent after OnConnect
+func (c *Client) connect(conn net.Conn) {
+	enc := protocol.NewEncoder(conn)
+	enc.SetMaxFrameSize(c.opts.MaxFrameSize)
+	chLost := make(chan struct{})
+	c.writeMutex.Lock()
+	select {
+	case <-c.done:
+		c.writeMutex.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	c.conn, c.enc, c.chLost = conn, enc, chLost
+	c.writeMutex.Unlock()
+	go c.read(conn)
+	if c.opts.Heartbeat > 0 {
+		go c.heartbeat(chLost)
+	}
+	if c.opts.OnConnect != nil {
+		c.opts.OnConnect(c)
+	}
+	c.flush(conn)
+}
+
+//flush buffered msg one every FlushInterval, then Send write directly
+//Send keep buffering meanwhile so the order is kept, what left is sent after next connect
+func (c *Client) flush(conn net.Conn) {
+	for {
+		c.writeMutex.Lock()
+		if c.conn != conn {
+			c.writeMutex.Unlock()
+			return
+		}
+		if len(c.buffer) == 0 {
+			c.buffer = nil
+			c.ready = true
+			c.writeMutex.Unlock()
+			return
+		}
+		m := c.buffer[0]
+		if err := c.enc.Encode(int32(m.cmd), m.msg); err != nil {
+			log.Printf("flush %d msg: %v\n", len(c.buffer), err)
+			c.writeMutex.Unlock()
+			return
+		}
+		c.buffer = c.buffer[1:]
+		more, chLost := len(c.buffer) > 0, c.chLost
+		c.writeMutex.Unlock()
+		if !more || c.opts.FlushInterval < 0 {
+			continue
+		}
+		select {
+		case <-time.After(c.opts.FlushInterval):
+		case <-chLost:
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+//disconnect conn is lost, reconnect or close
+func (c *Client) disconnect(conn net.Conn, err error) {
+	c.writeMutex.Lock()
+	if c.conn != conn {
+		//closed
+		c.writeMutex.Unlock()
+		return
+	}
+	c.conn, c.enc, c.ready = nil, nil, false
+	close(c.chLost)
+	c.writeMutex.Unlock()
+	conn.Close()
+	if !c.opts.Reconnect {
+		c.close(err)
+		return
+	}
+	if c.opts.OnDisconnect != nil {
+		c.opts.OnDisconnect(c, err)
+	}
+	go c.reconnect()
+}
+
+//reconnect until connected or closed, the wait is doubled after each failure
+//and randomized to half so that clients lost together do not come back together
+func (c *Client) reconnect() {
+	backoff := c.opts.MinBackoff
+	for {
+		wait := backoff/2 + time.Duration(c.rand.Int63n(int64(backoff/2)+1))
+		log.Printf("reconnect %s in %s\n", c.addr, wait)
+		select {
+		case <-time.After(wait):
+		case <-c.done:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.MaxBackoff)
+		conn, err := dial(ctx, c.addr, c.opts.TLS)
+		cancel()
+		if err == nil {
+			c.connect(conn)
+			return
+		}
+		log.Printf("reconnect %s: %v\n", c.addr, err)
+		if backoff *= 2; backoff > c.opts.MaxBackoff {
+			backoff = c.opts.MaxBackoff
+		}
+	}
+}
+
+//Handle push of cmd from server, a later Handle of the same cmd replace it
+func (c *Client) Handle(cmd protocol.S2CCmd, f func([]byte)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handles[int32(cmd)] = f
+}
+
+//HandleMessage handle push unmarshal as the type of prototype
+//client is closed if msg can not be unmarshal
+func (c *Client) HandleMessage(cmd protocol.S2CCmd, prototype proto.Message, f func(proto.Message)) {
+	c.Handle(cmd, func(buff []byte) {
+		msg, err := protocol.Unmarshal(buff, prototype)
+		if err != nil {
+			c.close(fmt.Errorf("protocol(%d): %v", cmd, err))
+			return
+		}
+		f(msg)
+	})
+}
+
+//Use append middleware, the first one is the outermost
+func (c *Client) Use(mw ...Middleware) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.middlewares = append(c.middlewares, mw...)
+	c.chain = c.route
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		c.chain = c.middlewares[i](c.chain)
+	}
+}
+
+func (c *Client) route(cmd int32, msg []byte) error {
+	c.mutex.RLock()
+	f, ok := c.handles[cmd]
+	c.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("protocol(%d) not find", cmd)
+	}
+	f(msg)
+	return nil
+}
+
+func (c *Client) dispatch(pkg *protocol.Package) {
+	c.mutex.RLock()
+	chain := c.chain
+	c.mutex.RUnlock()
+	if err := chain(pkg.Serial, pkg.Buff); err != nil {
+		log.Println(err)
+	}
+}
+
+func (c *Client) read(conn net.Conn) {
+	dec := protocol.NewDecoder(conn)
+	dec.SetMaxFrameSize(c.opts.MaxFrameSize)
+	//server answer every ping, a live connection always has something to read
+	timeout := c.opts.Heartbeat * time.Duration(c.opts.HeartbeatMisses)
+	for {
+		if timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+		pkg, err := dec.Decode()
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			err = ErrHeartbeatTimeout
+		}
+		if err != nil {
+			c.disconnect(conn, err)
+			return
+		}
+		if pkg.Request != 0 {
+			if !c.reply(pkg) {
+				log.Printf("request(%d) reply timeout\n", pkg.Request)
+			}
+			continue
+		}
+		c.dispatch(pkg)
+	}
+}
+
+//write must hold writeMutex, return chLost of the connection written
+func (c *Client) write(cmd protocol.C2SCmd, request uint32, msg proto.Message) (chan struct{}, error) {
+	select {
+	case <-c.done:
+		return nil, ErrClosed
+	default:
+	}
+	if c.conn == nil {
+		return nil, ErrDisconnected
+	}
+	return c.chLost, c.enc.EncodeRequest(int32(cmd), request, msg)
+}
+
+//Send msg without waiting for reply
+//while reconnecting msg is buffered up to Options.SendBuffer and sent after OnConnect
+func (c *Client) Send(cmd protocol.C2SCmd, msg proto.Message) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.ready || c.opts.SendBuffer <= 0 {
+		_, err := c.write(cmd, 0, msg)
+		return err
+	}
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	if len(c.buffer) >= c.opts.SendBuffer {
+		return ErrBufferFull
+	}
+	c.buffer = append(c.buffer, message{cmd, msg})
+	return nil
+}
+
+func (c *Client) heartbeat(chLost chan struct{}) {
+	ticker := time.NewTicker(c.opts.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.writeMutex.Lock()
+			_, err := c.write(protocol.C2SCmd_Ping, 0, &protocol.C2SPing{
+				Time: time.Now().UnixNano(),
+			})
+			c.writeMutex.Unlock()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+		case <-chLost:
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func showPong(msg proto.Message) {
+	rtt := time.Since(time.Unix(0, msg.(*protocol.S2CPong).Time))
+	if rtt > time.Second {
+		log.Printf("heartbeat rtt %s\n", rtt)
+	}
+}
+
+func (c *Client) close(err error) {
+	first := false
+	c.closeOnce.Do(func() {
+		c.writeMutex.Lock()
+		c.err = err
+		close(c.done)
+		conn := c.conn
+		c.conn, c.enc, c.ready = nil, nil, false
+		c.writeMutex.Unlock()
+		if conn != nil {
+			conn.Close()
+		}
+		first = true
+	})
+	//OnDisconnect may call Close
+	if first && c.opts.OnDisconnect != nil {
+		c.opts.OnDisconnect(c, err)
+	}
+}
+
+//Close logout and close connection, OnDisconnect is called with ErrClosed
+//the server drop the session at once instead of keeping it for resume
+func (c *Client) Close() error {
+	c.writeMutex.Lock()
+	if c.conn != nil {
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.write(protocol.C2SCmd_Logout, 0, &protocol.C2SLogout{})
+	}
+	c.writeMutex.Unlock()
+	c.close(ErrClosed)
+	return nil
+}
+
+//Done closed after Close, or connection lost without Reconnect
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+//Err reason of Done, nil before it
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//fakeServer reply login after pushing welcome, like the chat server
+func fakeServer(t *testing.T) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dec := protocol.NewDecoder(conn)
+		enc := protocol.NewEncoder(conn)
+		for {
+			pkg, err := dec.Decode()
+			if err != nil {
+				return
+			}
+			if protocol.C2SCmd(pkg.Serial) != protocol.C2SCmd_Login {
+				continue
+			}
+			enc.Encode(int32(protocol.S2CCmd_Welcome), &protocol.S2CWelcome{ServerName: "fake"})
+			enc.EncodeRequest(int32(protocol.S2CCmd_Reply), pkg.Request, &protocol.S2CLogin{Id: "bob"})
+		}
+	}()
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestPushAtLogin(t *testing.T) {
+	addr, stop := fakeServer(t)
+	defer stop()
+	welcome := make(chan string, 1)
+	c := New(&Options{
+		OnConnect: func(c *Client) {
+			var reply protocol.S2CLogin
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := c.Call(ctx, protocol.C2SCmd_Login, &protocol.C2SLogin{Username: "bob"}, &reply); err != nil {
+				t.Error(err)
+			}
+		},
+	})
+	c.HandleMessage(protocol.S2CCmd_Welcome, &protocol.S2CWelcome{}, func(msg proto.Message) {
+		welcome <- msg.(*protocol.S2CWelcome).ServerName
+	})
+	if err := c.Dial(context.Background(), addr); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	select {
+	case name := <-welcome:
+		if name != "fake" {
+			t.Fatalf("welcome from %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("welcome pushed at login is missed")
+	}
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		//read pings but never answer, like a dead link the kernel still accept writes for
+		dec := protocol.NewDecoder(conn)
+		for {
+			if _, err := dec.Decode(); err != nil {
+				return
+			}
+		}
+	}()
+	lost := make(chan error, 1)
+	c, err := Dial(context.Background(), l.Addr().String(), &Options{
+		Heartbeat:    20 * time.Millisecond,
+		OnDisconnect: func(c *Client, err error) { lost <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	select {
+	case err := <-lost:
+		if err != ErrHeartbeatTimeout {
+			t.Fatalf("got %v, want ErrHeartbeatTimeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("silent server is not noticed")
+	}
+}
+
+func TestFlushPaced(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	arrive := make(chan time.Time, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dec := protocol.NewDecoder(conn)
+		for {
+			pkg, err := dec.Decode()
+			if err != nil {
+				return
+			}
+			if protocol.C2SCmd(pkg.Serial) == protocol.C2SCmd_Chat {
+				arrive <- time.Now()
+			}
+		}
+	}()
+	const interval = 50 * time.Millisecond
+	c := New(&Options{SendBuffer: 10, FlushInterval: interval, Heartbeat: -1})
+	defer c.Close()
+	//buffered like while reconnecting
+	for i := 0; i < 3; i++ {
+		if err := c.Send(protocol.C2SCmd_Chat, &protocol.C2SChat{User: "bob", MsgId: uint64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Dial(context.Background(), l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	var last time.Time
+	for i := 0; i < 3; i++ {
+		select {
+		case at := <-arrive:
+			if i > 0 && at.Sub(last) < interval*4/5 {
+				t.Fatalf("msg %d arrive %s after the last", i, at.Sub(last))
+			}
+			last = at
+		case <-time.After(time.Second):
+			t.Fatalf("msg %d is not flushed", i)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Command slash command typed by user
+type Command struct {
+	//Args usage of arguments, the last one take the rest of line
+	Args []string
+	Help string
+	Run  func(args []string) error
+}
+
+//commands by name without slash
+var commands = map[string]*Command{
+	"msg": {
+		Args: []string{"<id|#room>", "<text>"},
+		Help: "send text to a player or a room, later lines without slash go to it too",
+		Run: func(args []string) error {
+			target = args[0]
+			return sendText(args[0], args[1])
+		},
+	},
+	"list": {
+		Help: "show online players",
+		Run:  func(args []string) error { return showPlayerList() },
+	},
+	"rooms": {
+		Help: "show rooms",
+		Run:  func(args []string) error { return showRoomList() },
+	},
+	"create": {
+		Args: []string{"<room>"},
+		Help: "create a room and join it",
+		Run:  func(args []string) error { return requestRoom(protocol.C2SCmd_RoomCreate, args[0]) },
+	},
+	"join": {
+		Args: []string{"<room>"},
+		Help: "join a room",
+		Run:  func(args []string) error { return requestRoom(protocol.C2SCmd_RoomJoin, args[0]) },
+	},
+	"leave": {
+		Args: []string{"<room>"},
+		Help: "leave a room",
+		Run:  func(args []string) error { return requestRoom(protocol.C2SCmd_RoomLeave, args[0]) },
+	},
+	"nick": {
+		Args: []string{"<name>"},
+		Help: "change your display name",
+		Run:  func(args []string) error { return setNick(args[0]) },
+	},
+	"quit": {
+		Help: "exit",
+		Run: func(args []string) error {
+			chStop <- fmt.Errorf("quit")
+			return nil
+		},
+	},
+}
+
+func init() {
+	//help list commands, it can not be in the literal of commands
+	commands["help"] = &Command{
+		Help: "show this help",
+		Run: func(args []string) error {
+			for _, name := range commandNames() {
+				cmd := commands[name]
+				usage := strings.Join(append([]string{"/" + name}, cmd.Args...), " ")
+				log.Printf("%-24s %s\n", usage, cmd.Help)
+			}
+			return nil
+		},
+	}
+}
+
+//target of lines without slash, set by /msg
+var target string
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//splitArgs split line into n words, the last one is the rest of line
+func splitArgs(line string, n int) []string {
+	var args []string
+	line = strings.TrimSpace(line)
+	for len(args) < n-1 && line != "" {
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			break
+		}
+		args = append(args, line[:i])
+		line = strings.TrimSpace(line[i:])
+	}
+	if line != "" {
+		args = append(args, line)
+	}
+	return args
+}
+
+//runLine run a slash command or send the line to target
+func runLine(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	if !strings.HasPrefix(line, "/") {
+		if target == "" {
+			return fmt.Errorf("no target, please input: /msg <id> <text>, /help for more")
+		}
+		return sendText(target, line)
+	}
+	v := splitArgs(line[1:], 2)
+	if len(v) == 0 {
+		return fmt.Errorf("please input a command, /help for more")
+	}
+	cmd, ok := commands[v[0]]
+	if !ok {
+		return fmt.Errorf("unknown command /%s, /help for more", v[0])
+	}
+	var args []string
+	if len(v) == 2 {
+		args = splitArgs(v[1], len(cmd.Args))
+	}
+	if len(args) != len(cmd.Args) {
+		return fmt.Errorf("please input: /%s %s", v[0], strings.Join(cmd.Args, " "))
+	}
+	return cmd.Run(args)
+}
+
+//complete command names at the beginning of line, or player ids
+func complete(before, word string) []string {
+	var candidates []string
+	if before == "" && strings.HasPrefix(word, "/") {
+		for _, name := range commandNames() {
+			if strings.HasPrefix(name, word[1:]) {
+				candidates = append(candidates, "/"+name)
+			}
+		}
+		return candidates
+	}
+	for _, info := range roster.List() {
+		if strings.HasPrefix(info.Id, word) {
+			candidates = append(candidates, info.Id)
+		}
+	}
+	return candidates
+}
+
+//sendText chat to player id, or room chat to #room
+func sendText(to, text string) error {
+	if strings.HasPrefix(to, "#") {
+		return cli.Send(protocol.C2SCmd_RoomChat, &protocol.C2SRoomChat{
+			Room:    to[1:],
+			Context: text,
+		})
+	}
+	msgID++
+	return cli.Send(protocol.C2SCmd_Chat, &protocol.C2SChat{
+		User:    to,
+		Context: text,
+		MsgId:   msgID,
+	})
+}
+
+func setNick(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var reply protocol.PlayerInfo
+	if err := cli.Call(ctx, protocol.C2SCmd_Nick, &protocol.C2SNick{Name: name}, &reply); err != nil {
+		return err
+	}
+	roster.Update(&reply, true)
+	log.Printf("you are now %s\n", reply.Name)
+	return nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//Completer candidates for word, before is the line in front of it
+type Completer func(before, word string) []string
+
+//LineReader read one input line without the line end
+type LineReader interface {
+	ReadLine() (string, error)
+	Close() error
+}
+
+//NewLineReader line editor with completion if stdin is a terminal, or plain lines
+//log output is drawn above the input line, or in the message pane of a full screen tui
+func NewLineReader(prompt string, complete Completer, tui bool) LineReader {
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return &plainReader{scanner: bufio.NewScanner(os.Stdin)}
+	}
+	e := &lineEditor{
+		in:       bufio.NewReader(os.Stdin),
+		prompt:   prompt,
+		complete: complete,
+		restore:  restore,
+		display:  &inlineDisplay{out: os.Stdout},
+	}
+	if tui {
+		e.display = newTUI(os.Stdout, int(os.Stdout.Fd()))
+		go e.watchResize()
+	}
+	return e
+}
+
+type plainReader struct {
+	scanner *bufio.Scanner
+}
+
+//Write print p as log output to stderr
+func (r *plainReader) Write(p []byte) (int, error) {
+	if _, err := os.Stderr.Write(sanitizeText(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (r *plainReader) ReadLine() (string, error) {
+	if r.scanner.Scan() {
+		return r.scanner.Text(), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+func (r *plainReader) Close() error {
+	return nil
+}
+
+//display draw for lineEditor, called with lineEditor.mutex held
+type display interface {
+	//print log output
+	print(p []byte)
+	//drawInput after every change
+	drawInput(prompt string, line []rune)
+	//enter line is done
+	enter(prompt string, line []rune)
+	//scroll history by pages, positive is back
+	scroll(pages int)
+	close()
+}
+
+//inlineDisplay print log output above the input line
+type inlineDisplay struct {
+	out io.Writer
+}
+
+func (d *inlineDisplay) print(p []byte) {
+	io.WriteString(d.out, "\r\033[K")
+	d.out.Write(sanitizeText(p))
+}
+
+func (d *inlineDisplay) drawInput(prompt string, line []rune) {
+	fmt.Fprintf(d.out, "\r\033[K%s%s", prompt, string(line))
+}
+
+func (d *inlineDisplay) enter(prompt string, line []rune) {
+	io.WriteString(d.out, "\n")
+}
+
+func (d *inlineDisplay) scroll(pages int) {}
+
+func (d *inlineDisplay) close() {
+	io.WriteString(d.out, "\r\033[K")
+}
+
+//lineEditor edit at the end of line, tab complete the last word
+type lineEditor struct {
+	in       *bufio.Reader
+	prompt   string
+	complete Completer
+	restore  func()
+	display  display
+
+	mutex  sync.Mutex
+	line   []rune
+	closed bool
+}
+
+//redraw must hold mutex
+func (e *lineEditor) redraw() {
+	if !e.closed {
+		e.display.drawInput(e.prompt, e.line)
+	}
+}
+
+//Write print p as log output
+func (e *lineEditor) Write(p []byte) (int, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.closed {
+		if _, err := os.Stdout.Write(sanitizeText(p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	e.display.print(p)
+	e.redraw()
+	return len(p), nil
+}
+
+//Close restore terminal
+func (e *lineEditor) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !e.closed {
+		e.display.close()
+		e.restore()
+		e.closed = true
+	}
+	return nil
+}
+
+func (e *lineEditor) watchResize() {
+	ch := make(chan os.Signal, 1)
+	notifyResize(ch)
+	for range ch {
+		e.mutex.Lock()
+		e.redraw()
+		e.mutex.Unlock()
+	}
+}
+
+func (e *lineEditor) ReadLine() (string, error) {
+	e.mutex.Lock()
+	e.line = e.line[:0]
+	e.redraw()
+	e.mutex.Unlock()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		var seq string
+		if r == 27 {
+			seq = e.readEscape()
+		}
+		e.mutex.Lock()
+		switch {
+		case r == '\r' || r == '\n':
+			line := string(e.line)
+			e.display.enter(e.prompt, e.line)
+			e.line = e.line[:0]
+			e.mutex.Unlock()
+			return line, nil
+		case r == 4: //ctrl-d
+			if len(e.line) == 0 {
+				e.mutex.Unlock()
+				return "", io.EOF
+			}
+		case r == 127 || r == 8: //backspace
+			if len(e.line) > 0 {
+				e.line = e.line[:len(e.line)-1]
+			}
+		case r == 21: //ctrl-u
+			e.line = e.line[:0]
+		case r == '\t':
+			e.completeWord()
+		case r == 27:
+			switch seq {
+			case "[5~": //page up
+				e.display.scroll(1)
+			case "[6~": //page down
+				e.display.scroll(-1)
+			}
+		case r >= ' ':
+			e.line = append(e.line, r)
+		}
+		e.redraw()
+		e.mutex.Unlock()
+	}
+}
+
+//readEscape read escape sequence after ESC such as [5~ of page up, empty for a lone ESC or Alt+key
+//the terminal write a sequence at once, so only bytes already read with ESC are taken and it never block
+//the key after a lone ESC or Alt is left to ReadLine
+func (e *lineEditor) readEscape() string {
+	if e.in.Buffered() == 0 {
+		return ""
+	}
+	if b, err := e.in.Peek(1); err != nil || b[0] != '[' {
+		return ""
+	}
+	e.in.ReadByte()
+	seq := []byte{'['}
+	for e.in.Buffered() > 0 {
+		b, _ := e.in.ReadByte()
+		seq = append(seq, b)
+		if b >= 0x40 && b <= 0x7e {
+			return string(seq)
+		}
+	}
+	return ""
+}
+
+//completeWord extend the last word to the common prefix of candidates
+//list them if it can not be extended, must hold mutex
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	line := string(e.line)
+	start := strings.LastIndex(line, " ") + 1
+	word := line[start:]
+	candidates := e.complete(line[:start], word)
+	if len(candidates) == 0 {
+		return
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	if len(candidates) == 1 {
+		prefix += " "
+	}
+	if len(prefix) > len(word) {
+		e.line = []rune(line[:start] + prefix)
+		return
+	}
+	e.display.print([]byte(strings.Join(candidates, "  ") + "\n"))
+}
+
+//sanitize replace control characters, chat from others can not move the cursor
+//C1 controls are replaced too, some terminals take U+009B as CSI
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 127 || (r >= 0x80 && r <= 0x9f) {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+//sanitizeText sanitize every line of log output, line ends are kept
+func sanitizeText(p []byte) []byte {
+	lines := strings.Split(string(p), "\n")
+	for i, line := range lines {
+		lines[i] = sanitize(line)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
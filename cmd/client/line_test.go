@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "bob: hi\n", "bob: hi\n"},
+		{"cjk", "bob: 你好\n", "bob: 你好\n"},
+		{"clear screen", "bob: \033[2J\033[H\n", "bob: ?[2J?[H\n"},
+		{"title", "\033]0;pwned\007", "?]0;pwned?"},
+		{"c1 csi", "bob: \u009b2J\n", "bob: ?2J\n"},
+		{"carriage return", "bob: a\rb\n", "bob: a?b\n"},
+		{"lines", "a\nb\n", "a\nb\n"},
+	}
+	for _, tt := range tests {
+		if got := string(sanitizeText([]byte(tt.in))); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadEscape(t *testing.T) {
+	tests := []struct {
+		name, in, seq, next string
+	}{
+		{"page up", "\033[5~x", "[5~", "x"},
+		{"alt key", "\033x", "", "x"},
+		{"lone esc", "\033", "", ""},
+	}
+	for _, tt := range tests {
+		e := &lineEditor{in: bufio.NewReader(strings.NewReader(tt.in))}
+		e.in.ReadRune()
+		if seq := e.readEscape(); seq != tt.seq {
+			t.Errorf("%s: got %q, want %q", tt.name, seq, tt.seq)
+		}
+		rest, _ := ioutil.ReadAll(e.in)
+		if string(rest) != tt.next {
+			t.Errorf("%s: left %q, want %q", tt.name, rest, tt.next)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/client"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+var chStop chan error
+var chSig chan os.Signal
+var cli *client.Client
+var roster = NewRoster()
+var msgID uint64
+var readReceipt bool
+
+func init() {
+	chStop = make(chan error)
+	chSig = make(chan os.Signal)
+}
+
+//registerHandles of every push, before Dial
+func registerHandles(c *client.Client) {
+	c.Handle(protocol.S2CCmd_Invalid, stopClient)
+	c.HandleMessage(protocol.S2CCmd_Result, &protocol.S2CResult{}, showMsg)
+	c.HandleMessage(protocol.S2CCmd_Shutdown, &protocol.S2CShutdown{}, serverShutdown)
+	c.HandleMessage(protocol.S2CCmd_RoomMsg, &protocol.S2CRoomChat{}, showRoomMsg)
+	c.HandleMessage(protocol.S2CCmd_Welcome, &protocol.S2CWelcome{}, showWelcome)
+	c.HandleMessage(protocol.S2CCmd_Roster, &protocol.S2CRoster{}, showRoster)
+	c.HandleMessage(protocol.S2CCmd_Presence, &protocol.S2CPresence{}, showPresence)
+	c.HandleMessage(protocol.S2CCmd_ChatMsg, &protocol.S2CChat{}, showChat)
+	c.HandleMessage(protocol.S2CCmd_ChatStatus, &protocol.S2CChatStatus{}, showChatStatus)
+	c.HandleMessage(protocol.S2CCmd_Error, &protocol.S2CError{}, showError)
+}
+
+func logHandle(next client.HandleFunc) client.HandleFunc {
+	return func(cmd int32, msg []byte) error {
+		err := next(cmd, msg)
+		log.Printf("protocol(%d) %d bytes, err: %v\n", cmd, len(msg), err)
+		return err
+	}
+}
+
+func stopClient(msg []byte) {
+	chStop <- fmt.Errorf("data invalid")
+}
+
+func showMsg(msg proto.Message) {
+	log.Println(msg.(*protocol.S2CResult).Context)
+}
+
+//showError error of message without request, such as throttled chat
+func showError(msg proto.Message) {
+	e := msg.(*protocol.S2CError)
+	if e.Code == protocol.ErrCode_ErrThrottled {
+		log.Printf("%v, retry after %s\n", e, time.Duration(e.RetryAfter))
+		return
+	}
+	log.Println(e)
+}
+
+func serverShutdown(msg proto.Message) {
+	chStop <- fmt.Errorf("server going down: %s", msg.(*protocol.S2CShutdown).Reason)
+}
+
+//loadToken read guest token from path, a new random token is saved if missing
+//the same token login as the same guest every time
+func loadToken(path string) (string, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(buff)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, ioutil.WriteFile(path, []byte(token+"\n"), 0600)
+}
+
+func login(username, password, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var reply protocol.S2CLogin
+	if err := cli.Call(ctx, protocol.C2SCmd_Login, &protocol.C2SLogin{
+		Username:   username,
+		Credential: password,
+		Token:      token,
+	}, &reply); err != nil {
+		return err
+	}
+	if reply.Resumed {
+		log.Printf("session resumed, your id: %s\n", reply.Id)
+		return nil
+	}
+	log.Printf("login, your id: %s\n", reply.Id)
+	return nil
+}
+
+func showChat(msg proto.Message) {
+	chat := msg.(*protocol.S2CChat)
+	if chat.Offline {
+		log.Printf("%s(offline %s): %s\n", chat.From, time.Unix(0, chat.Time).Format("01-02 15:04"), chat.Context)
+		return
+	}
+	log.Printf("%s: %s\n", chat.From, chat.Context)
+	if !readReceipt || chat.MsgId == 0 {
+		return
+	}
+	if err := cli.Send(protocol.C2SCmd_ReadReceipt, &protocol.C2SReadReceipt{
+		From:  chat.From,
+		MsgId: chat.MsgId,
+	}); err != nil {
+		log.Println(err)
+	}
+}
+
+func showChatStatus(msg proto.Message) {
+	status := msg.(*protocol.S2CChatStatus)
+	if status.Reason != "" {
+		log.Printf("message(%d) to %s: %s, %s\n", status.MsgId, status.Target, status.State, status.Reason)
+		return
+	}
+	log.Printf("message(%d) to %s: %s\n", status.MsgId, status.Target, status.State)
+}
+
+func showWelcome(msg proto.Message) {
+	welcome := msg.(*protocol.S2CWelcome)
+	roster.SetSelf(welcome.Self.Id)
+	log.Printf("welcome to %s(%s), you are %s, id: %s\n",
+		welcome.ServerName, welcome.Version, welcome.Self.Name, welcome.Self.Id)
+}
+
+//showRoster first page is pushed at login, the rest are asked in background
+func showRoster(msg proto.Message) {
+	page := msg.(*protocol.S2CRoster)
+	roster.Reset(page.Players)
+	if !page.More || len(page.Players) == 0 {
+		printRoster()
+		return
+	}
+	//handle can not wait for a reply
+	go func() {
+		players, err := fetchRoster(page.Players[len(page.Players)-1].Id)
+		roster.Merge(players)
+		if err != nil {
+			log.Printf("roster: %v\n", err)
+		}
+		printRoster()
+	}()
+}
+
+//rosterPageInterval between roster pages, below the default player rate with room for chat
+const rosterPageInterval = 100 * time.Millisecond
+
+//fetchRoster ask roster pages after id until the last one
+//a throttled page is asked again after the server told
+func fetchRoster(after string) ([]*protocol.PlayerInfo, error) {
+	var players []*protocol.PlayerInfo
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		var reply protocol.S2CRoster
+		err := cli.Call(ctx, protocol.C2SCmd_PlayerList, &protocol.C2SPlayerList{After: after}, &reply)
+		cancel()
+		if e, ok := err.(*protocol.S2CError); ok && e.Code == protocol.ErrCode_ErrThrottled {
+			time.Sleep(time.Duration(e.RetryAfter))
+			continue
+		}
+		if err != nil {
+			return players, err
+		}
+		players = append(players, reply.Players...)
+		if !reply.More || len(reply.Players) == 0 {
+			return players, nil
+		}
+		after = reply.Players[len(reply.Players)-1].Id
+		time.Sleep(rosterPageInterval)
+	}
+}
+
+func printRoster() {
+	self := roster.GetSelf()
+	for _, info := range roster.List() {
+		if info.Id == self {
+			log.Printf("player %s: %s (you)\n", info.Id, info.Name)
+			continue
+		}
+		log.Printf("player %s: %s\n", info.Id, info.Name)
+	}
+}
+
+func showPresence(msg proto.Message) {
+	presence := msg.(*protocol.S2CPresence)
+	roster.Update(presence.Player, presence.Online)
+	state := "offline"
+	if presence.Online {
+		state = "online"
+	}
+	log.Printf("player %s: %s %s\n", presence.Player.Id, presence.Player.Name, state)
+}
+
+func showPlayerList() error {
+	players, err := fetchRoster("")
+	if err != nil {
+		return err
+	}
+	roster.Reset(players)
+	printRoster()
+	return nil
+}
+
+func requestRoom(cmd protocol.C2SCmd, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var reply protocol.S2CRoom
+	if err := cli.Call(ctx, cmd, &protocol.C2SRoom{Name: name}, &reply); err != nil {
+		return err
+	}
+	log.Printf("%s room %s, members: %v\n", cmd.String(), reply.Name, reply.Members)
+	return nil
+}
+
+func showRoomList() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var reply protocol.S2CRoomList
+	if err := cli.Call(ctx, protocol.C2SCmd_RoomList, &protocol.C2SRoomList{}, &reply); err != nil {
+		return err
+	}
+	for _, room := range reply.Rooms {
+		log.Printf("room %s, members: %v\n", room.Name, room.Members)
+	}
+	return nil
+}
+
+func showRoomMsg(msg proto.Message) {
+	chat := msg.(*protocol.S2CRoomChat)
+	log.Printf("[%s] %s: %s\n", chat.Room, chat.From, chat.Context)
+}
+
+func handleSignal() {
+	signal.Notify(chSig, os.Interrupt)
+	s := <-chSig
+	chStop <- fmt.Errorf("stop clinet: %s", s.String())
+}
+
+func main() {
+	maxFrameSize := flag.Int("maxframe", protocol.MaxFrameSize, "max package body size")
+	verbose := flag.Bool("verbose", false, "log every package")
+	interval := flag.Duration("heartbeat", client.DefaultHeartbeat, "heartbeat interval, 0 disable")
+	username := flag.String("user", "", "login username")
+	password := flag.String("password", "", "login password")
+	tokenFile := flag.String("token", "guest.token", "guest token file, used when user is empty")
+	addr := flag.String("addr", "127.0.0.1:7788", "server address, unix socket as unix:/path")
+	useTLS := flag.Bool("tls", false, "connect server with tls")
+	ca := flag.String("ca", "", "ca bundle to verify server, empty use system roots")
+	certFile := flag.String("cert", "", "client certificate for mutual tls, login as its CN without user")
+	keyFile := flag.String("key", "", "client key for mutual tls")
+	serverName := flag.String("servername", "", "server name to verify, empty use host of addr")
+	flag.BoolVar(&readReceipt, "receipt", false, "send read receipt for every chat")
+	reconnect := flag.Bool("reconnect", true, "reconnect and resume session after connection lost")
+	sendBuffer := flag.Int("sendbuffer", 100, "msg kept while reconnecting")
+	fullScreen := flag.Bool("tui", false, "full screen terminal ui with message pane and roster")
+	flag.Parse()
+	var config *tls.Config
+	if *useTLS {
+		host := *serverName
+		if host == "" {
+			var err error
+			if host, _, err = net.SplitHostPort(*addr); err != nil {
+				log.Fatalln("please input -servername:", err)
+			}
+		}
+		var err error
+		if config, err = loadTLSConfig(*ca, *certFile, *keyFile, host); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	var token string
+	if *username == "" {
+		var err error
+		if token, err = loadToken(*tokenFile); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	go handleSignal()
+
+	input := NewLineReader("> ", complete, *fullScreen)
+	//chat from others is sanitized by input before it reach the terminal
+	if w, ok := input.(io.Writer); ok {
+		log.SetOutput(w)
+		if *fullScreen {
+			log.SetFlags(log.Ltime)
+		}
+	}
+
+	log.Println("connect server...")
+	if *interval == 0 {
+		*interval = -1
+	}
+	c := client.New(&client.Options{
+		TLS:           config,
+		MaxFrameSize:  *maxFrameSize,
+		Heartbeat:     *interval,
+		RetryInterval: time.Second,
+		Reconnect:     *reconnect,
+		SendBuffer:    *sendBuffer,
+		OnConnect: func(c *client.Client) {
+			log.Printf("%s established", *addr)
+			if err := login(*username, *password, token); err != nil {
+				//main is not waiting before Dial return
+				go func() { chStop <- fmt.Errorf("login: %v", err) }()
+			}
+		},
+		OnDisconnect: func(c *client.Client, err error) {
+			if c.Err() == nil {
+				log.Printf("connection lost: %v, reconnecting...\n", err)
+				return
+			}
+			//main is not waiting after it close cli
+			select {
+			case chStop <- err:
+			default:
+			}
+		},
+	})
+	registerHandles(c)
+	if *verbose {
+		c.Use(logHandle)
+	}
+	cli = c
+	err := c.Dial(context.Background(), *addr)
+	if err != nil {
+		input.Close()
+		log.Fatalln(err)
+	}
+	go func() {
+		log.Println("type /help for commands")
+		for {
+			line, err := input.ReadLine()
+			if err != nil {
+				chStop <- fmt.Errorf("input: %v", err)
+				return
+			}
+			if err := runLine(line); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	// Stress test
+	// go func() {
+	// 	for {
+	// 		cli.Send(protocol.C2SCmd_Chat, &protocol.C2SChat{
+	// 			User:    "guest",
+	// 			Context: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	// 		})
+	// 	}
+	// }()
+
+	err = <-chStop
+	//logout, or the server keep the session for resume
+	cli.Close()
+	input.Close()
+	log.Println(err)
+}
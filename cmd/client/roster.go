@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Roster login players known by client, kept by roster and presence
+type Roster struct {
+	mutex   sync.RWMutex
+	self    string
+	players map[string]*protocol.PlayerInfo
+}
+
+//NewRoster empty roster
+func NewRoster() *Roster {
+	return &Roster{
+		players: make(map[string]*protocol.PlayerInfo),
+	}
+}
+
+//SetSelf own user id
+func (r *Roster) SetSelf(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.self = id
+}
+
+//GetSelf own user id
+func (r *Roster) GetSelf() string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.self
+}
+
+//Reset replace every player
+func (r *Roster) Reset(players []*protocol.PlayerInfo) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.players = make(map[string]*protocol.PlayerInfo, len(players))
+	for _, info := range players {
+		r.players[info.Id] = info
+	}
+}
+
+//Merge add players of later roster pages
+func (r *Roster) Merge(players []*protocol.PlayerInfo) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, info := range players {
+		r.players[info.Id] = info
+	}
+}
+
+//Update add online player or remove offline player
+func (r *Roster) Update(info *protocol.PlayerInfo, online bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if online {
+		r.players[info.Id] = info
+		return
+	}
+	delete(r.players, info.Id)
+}
+
+//List players order by id
+func (r *Roster) List() []*protocol.PlayerInfo {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	list := make([]*protocol.PlayerInfo, 0, len(r.players))
+	for _, info := range r.players {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
@@ -0,0 +1,19 @@
+// +build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+//makeRaw line editing is only supported on linux, lines are read as typed
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal not supported")
+}
+
+func termSize(fd int) (int, int, error) {
+	return 0, 0, errors.New("terminal size not supported")
+}
+
+func notifyResize(ch chan<- os.Signal) {}
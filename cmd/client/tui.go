@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+const (
+	//maxHistory lines kept in message pane
+	maxHistory = 1000
+	//rosterWidth columns of roster sidebar
+	rosterWidth = 24
+)
+
+//tui full screen display, message pane on the left, roster on the right and input at the bottom
+//the whole screen is drawn again on every log output and key, roster change always come with log output
+type tui struct {
+	out     io.Writer
+	fd      int
+	history []string
+	//offset wrapped lines scrolled back from the bottom
+	offset int
+}
+
+//newTUI switch to the alternate screen, close switch back
+func newTUI(out io.Writer, fd int) *tui {
+	io.WriteString(out, "\033[?1049h")
+	return &tui{out: out, fd: fd}
+}
+
+func (t *tui) size() (int, int) {
+	w, h, err := termSize(t.fd)
+	if err != nil || w <= 0 || h <= 0 {
+		w, h = 80, 24
+	}
+	if w < rosterWidth+20 {
+		w = rosterWidth + 20
+	}
+	if h < 5 {
+		h = 5
+	}
+	return w, h
+}
+
+func (t *tui) print(p []byte) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		t.history = append(t.history, line)
+	}
+	if over := len(t.history) - maxHistory; over > 0 {
+		t.history = t.history[over:]
+	}
+}
+
+func (t *tui) enter(prompt string, line []rune) {
+	t.print([]byte(prompt + string(line)))
+	t.offset = 0
+}
+
+func (t *tui) scroll(pages int) {
+	_, h := t.size()
+	t.offset += pages * (h - 3)
+	if t.offset < 0 {
+		t.offset = 0
+	}
+}
+
+func (t *tui) close() {
+	io.WriteString(t.out, "\033[?1049l")
+}
+
+//wrap history into lines of width columns
+func (t *tui) wrap(width int) []string {
+	var lines []string
+	for _, line := range t.history {
+		var cur []rune
+		w := 0
+		for _, r := range sanitize(line) {
+			rw := runeWidth(r)
+			if w+rw > width {
+				lines = append(lines, string(cur))
+				cur, w = nil, 0
+			}
+			cur = append(cur, r)
+			w += rw
+		}
+		lines = append(lines, string(cur))
+	}
+	return lines
+}
+
+func (t *tui) drawInput(prompt string, line []rune) {
+	w, h := t.size()
+	paneW, paneH := w-rosterWidth-1, h-2
+	lines := t.wrap(paneW)
+	if limit := len(lines) - paneH; t.offset > limit {
+		t.offset = limit
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+	end := len(lines) - t.offset
+	start := end - paneH
+	if start < 0 {
+		start = 0
+	}
+	visible := lines[start:end]
+	side := rosterLines(paneH)
+
+	var b bytes.Buffer
+	b.WriteString("\033[?25l")
+	for row := 0; row < paneH; row++ {
+		text := ""
+		if row < len(visible) {
+			text = visible[row]
+		}
+		fmt.Fprintf(&b, "\033[%d;1H%s│%s", row+1, fit(text, paneW), fit(side[row], rosterWidth))
+	}
+	bar := strings.Repeat("─", paneW) + "┴" + strings.Repeat("─", rosterWidth)
+	if t.offset > 0 {
+		bar = fit(fmt.Sprintf("── %d lines below, PgDn ", t.offset), paneW) + "┴" + strings.Repeat("─", rosterWidth)
+	}
+	fmt.Fprintf(&b, "\033[%d;1H%s", h-1, bar)
+	input := []rune(sanitize(prompt + string(line)))
+	for textWidth(input) > w-1 {
+		input = input[1:]
+	}
+	fmt.Fprintf(&b, "\033[%d;1H\033[K%s\033[?25h", h, string(input))
+	t.out.Write(b.Bytes())
+}
+
+//rosterLines sidebar of n rows, self is marked with *
+func rosterLines(n int) []string {
+	side := make([]string, n)
+	players := roster.List()
+	self := roster.GetSelf()
+	side[0] = fmt.Sprintf(" players (%d)", len(players))
+	for i, info := range players {
+		row := i + 1
+		if row >= n {
+			break
+		}
+		if row == n-1 && i < len(players)-1 {
+			side[row] = fmt.Sprintf(" +%d more", len(players)-i)
+			break
+		}
+		mark := "  "
+		if info.Id == self {
+			mark = "* "
+		}
+		if info.Name != info.Id {
+			side[row] = fmt.Sprintf("%s%s (%s)", mark, info.Name, info.Id)
+			continue
+		}
+		side[row] = mark + info.Name
+	}
+	return side
+}
+
+//fit s into exactly width columns, a wide character not fit is left out
+func fit(s string, width int) string {
+	var out []rune
+	w := 0
+	for _, r := range sanitize(s) {
+		rw := runeWidth(r)
+		if w+rw > width {
+			break
+		}
+		out = append(out, r)
+		w += rw
+	}
+	return string(out) + strings.Repeat(" ", width-w)
+}
+
+//wideRanges east asian wide and fullwidth characters, 2 columns in a terminal
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},   //hangul jamo
+	{0x2e80, 0x303e},   //cjk radicals, symbols and punctuation
+	{0x3041, 0x33ff},   //kana, bopomofo, cjk compatibility
+	{0x3400, 0x4dbf},   //cjk extension a
+	{0x4e00, 0x9fff},   //cjk unified ideographs
+	{0xa000, 0xa4cf},   //yi
+	{0xac00, 0xd7a3},   //hangul syllables
+	{0xf900, 0xfaff},   //cjk compatibility ideographs
+	{0xfe30, 0xfe4f},   //cjk compatibility forms
+	{0xff00, 0xff60},   //fullwidth forms
+	{0xffe0, 0xffe6},   //fullwidth signs
+	{0x1f300, 0x1f64f}, //emoji
+	{0x1f900, 0x1f9ff}, //supplemental symbols
+	{0x20000, 0x3fffd}, //cjk extension b and later
+}
+
+//runeWidth terminal columns of r
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me) || r == 0x200b {
+		return 0
+	}
+	for _, wr := range wideRanges {
+		if r < wr.lo {
+			break
+		}
+		if r <= wr.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+//textWidth terminal columns of r
+func textWidth(r []rune) int {
+	w := 0
+	for _, c := range r {
+		w += runeWidth(c)
+	}
+	return w
+}
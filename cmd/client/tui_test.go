@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"bob", 5, "bob  "},
+		{"bobalice", 5, "bobal"},
+		{"你好", 5, "你好 "},
+		{"你好世界", 5, "你好 "},
+		{"a你好", 4, "a你 "},
+		{"é", 2, "é "},
+	}
+	for _, tt := range tests {
+		got := fit(tt.in, tt.width)
+		if got != tt.want {
+			t.Errorf("fit(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+		if w := textWidth([]rune(got)); w != tt.width {
+			t.Errorf("fit(%q, %d) take %d columns", tt.in, tt.width, w)
+		}
+	}
+}
+
+func TestWrapWide(t *testing.T) {
+	ui := &tui{history: []string{"你好世界ab"}}
+	lines := ui.wrap(5)
+	want := []string{"你好", "世界a", "b"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("got %q, want %q", lines, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"io"
+
+	"github.com/golang/protobuf/proto"
+)
+
+//Decoder read package from a stream
+type Decoder struct {
+	r       io.Reader
+	buff    []byte
+	data    []byte
+	maxsize int
+}
+
+//NewDecoder read from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:       r,
+		buff:    make([]byte, MaxSize),
+		maxsize: MaxFrameSize,
+	}
+}
+
+//SetMaxFrameSize limit package body size, default MaxFrameSize
+func (d *Decoder) SetMaxFrameSize(size int) {
+	d.maxsize = size
+}
+
+// Next block until a whole package arrive
+// return protocol id, body([]byte), error
+// ErrCorruptFrame and ErrFrameTooLarge mean the stream can not be recovered
+func (d *Decoder) Next() (int32, []byte, error) {
+	pkg, err := d.Decode()
+	if err != nil {
+		return 0, nil, err
+	}
+	return pkg.Serial, pkg.Buff, nil
+}
+
+// Decode same as Next but return the whole *Package with request id
+func (d *Decoder) Decode() (*Package, error) {
+	for {
+		offset, pkg, err := UnPackPackage(d.data, d.maxsize)
+		if err == nil {
+			d.data = d.data[offset:]
+			return pkg, nil
+		}
+		if err != ErrShortFrame {
+			return nil, err
+		}
+		n, err := d.r.Read(d.buff)
+		if n > 0 {
+			d.data = append(d.data, d.buff[:n]...)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		if err == io.EOF && len(d.data) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+}
+
+//Encoder write package to a stream
+type Encoder struct {
+	w       io.Writer
+	maxsize int
+}
+
+//NewEncoder write to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:       w,
+		maxsize: MaxFrameSize,
+	}
+}
+
+//SetMaxFrameSize refuse to send package body larger than size, default MaxFrameSize
+func (e *Encoder) SetMaxFrameSize(size int) {
+	e.maxsize = size
+}
+
+// Encode params protocol id, *proto.Message
+// return error
+func (e *Encoder) Encode(serial int32, m proto.Message) error {
+	return e.EncodeRequest(serial, 0, m)
+}
+
+// EncodeRequest params protocol id, request id, *proto.Message
+// return error
+func (e *Encoder) EncodeRequest(serial int32, request uint32, m proto.Message) error {
+	buff, err := PackRequest(serial, request, m)
+	if err != nil {
+		return err
+	}
+	if len(buff)-headsize > e.maxsize {
+		return ErrFrameTooLarge
+	}
+	_, err = e.w.Write(buff)
+	return err
+}
@@ -0,0 +1,175 @@
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+//chunkReader return at most the next chunk size bytes on every Read
+type chunkReader struct {
+	data   []byte
+	chunks []int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := len(r.data)
+	if len(r.chunks) > 0 {
+		n, r.chunks = r.chunks[0], r.chunks[1:]
+		if n > len(r.data) {
+			n = len(r.data)
+		}
+	}
+	n = copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func mustPack(t *testing.T, serial int32, request uint32, context string) []byte {
+	buff, err := PackRequest(serial, request, &S2CResult{Context: context})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buff
+}
+
+func head(size int) []byte {
+	b, _ := int2bytes(size)
+	return b
+}
+
+func TestUnPackPackage(t *testing.T) {
+	frame := mustPack(t, 3, 7, "hello")
+	tests := []struct {
+		name   string
+		data   []byte
+		max    int
+		offset int
+		err    error
+	}{
+		{"empty", nil, MaxFrameSize, 0, ErrShortFrame},
+		{"partial head", frame[:headsize-1], MaxFrameSize, 0, ErrShortFrame},
+		{"head only", frame[:headsize], MaxFrameSize, 0, ErrShortFrame},
+		{"partial body", frame[:len(frame)-1], MaxFrameSize, 0, ErrShortFrame},
+		{"whole", frame, MaxFrameSize, len(frame), nil},
+		{"whole and more", append(append([]byte{}, frame...), frame[:2]...), MaxFrameSize, len(frame), nil},
+		{"too large", frame, len(frame) - headsize - 1, 0, ErrFrameTooLarge},
+		{"too large head only", head(MaxFrameSize + 1), MaxFrameSize, 0, ErrFrameTooLarge},
+		{"corrupt body", append(head(3), 0xff, 0xff, 0xff), MaxFrameSize, 0, ErrCorruptFrame},
+	}
+	for _, tt := range tests {
+		offset, pkg, err := UnPackPackage(tt.data, tt.max)
+		if err != tt.err || offset != tt.offset {
+			t.Errorf("%s: got %d, %v, want %d, %v", tt.name, offset, err, tt.offset, tt.err)
+			continue
+		}
+		if err == nil && (pkg.Serial != 3 || pkg.Request != 7) {
+			t.Errorf("%s: got serial %d request %d", tt.name, pkg.Serial, pkg.Request)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	a := mustPack(t, 1, 0, "a")
+	b := mustPack(t, 2, 5, "bb")
+	two := append(append([]byte{}, a...), b...)
+	tests := []struct {
+		name    string
+		data    []byte
+		chunks  []int
+		serials []int32
+		err     error
+	}{
+		{"one byte per read", two, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, []int32{1, 2}, io.EOF},
+		{"split in head", two, []int{2, len(two)}, []int32{1, 2}, io.EOF},
+		{"two frames one read", two, nil, []int32{1, 2}, io.EOF},
+		{"frame and a half", two, []int{len(a) + 3, len(two)}, []int32{1, 2}, io.EOF},
+		{"eof in head", append(append([]byte{}, a...), b[:2]...), nil, []int32{1}, io.ErrUnexpectedEOF},
+		{"eof in body", append(append([]byte{}, a...), b[:len(b)-1]...), nil, []int32{1}, io.ErrUnexpectedEOF},
+		{"oversized head", append(append([]byte{}, a...), head(MaxFrameSize+1)...), nil, []int32{1}, ErrFrameTooLarge},
+		{"corrupt body", append(append([]byte{}, a...), append(head(3), 0xff, 0xff, 0xff)...), nil, []int32{1}, ErrCorruptFrame},
+	}
+	for _, tt := range tests {
+		dec := NewDecoder(&chunkReader{data: tt.data, chunks: tt.chunks})
+		var serials []int32
+		var err error
+		for {
+			var pkg *Package
+			if pkg, err = dec.Decode(); err != nil {
+				break
+			}
+			serials = append(serials, pkg.Serial)
+		}
+		if err != tt.err || len(serials) != len(tt.serials) {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.name, serials, err, tt.serials, tt.err)
+			continue
+		}
+		for i := range serials {
+			if serials[i] != tt.serials[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, serials, tt.serials)
+				break
+			}
+		}
+	}
+}
+
+func TestDecoderRequest(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader(mustPack(t, 4, 9, "hi")))
+	pkg, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg S2CResult
+	if _, err := Unmarshal(pkg.Buff, &msg); err != nil || pkg.Request != 9 {
+		t.Fatalf("got request %d, %v", pkg.Request, err)
+	}
+}
+
+func TestEncoderMaxFrameSize(t *testing.T) {
+	var buff bytes.Buffer
+	enc := NewEncoder(&buff)
+	enc.SetMaxFrameSize(16)
+	if err := enc.Encode(1, &S2CResult{Context: "short"}); err != nil {
+		t.Fatal(err)
+	}
+	n := buff.Len()
+	if err := enc.Encode(1, &S2CResult{Context: "much longer than sixteen bytes"}); err != ErrFrameTooLarge {
+		t.Fatalf("got %v, want ErrFrameTooLarge", err)
+	}
+	if buff.Len() != n {
+		t.Fatal("too large frame is written")
+	}
+	dec := NewDecoder(&buff)
+	dec.SetMaxFrameSize(16)
+	if serial, _, err := dec.Next(); err != nil || serial != 1 {
+		t.Fatalf("got %d, %v", serial, err)
+	}
+}
+
+func TestLegacyUnlimited(t *testing.T) {
+	big := strings.Repeat("x", MaxFrameSize+1)
+	frame := mustPack(t, 5, 0, big)
+	offset, serial, buff := UnPack(frame)
+	if offset != len(frame) || serial != 5 || len(buff) == 0 {
+		t.Fatalf("got %d, %d, %d bytes", offset, serial, len(buff))
+	}
+	if _, _, _, err := UnPackFrame(frame); err != ErrFrameTooLarge {
+		t.Fatalf("got %v, want ErrFrameTooLarge", err)
+	}
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		Send2Client(client, S2CCmd_Result, &S2CResult{Context: big})
+		client.Close()
+	}()
+	dec := NewDecoder(server)
+	dec.SetMaxFrameSize(unlimited)
+	if _, _, err := dec.Next(); err != nil {
+		t.Fatal(err)
+	}
+}
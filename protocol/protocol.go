@@ -3,7 +3,10 @@ package protocol
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"unsafe"
 
 	"github.com/golang/protobuf/proto"
@@ -15,6 +18,9 @@ const MaxSize = 1024
 
 const headsize = int(unsafe.Sizeof(uint32(0)))
 
+//HeadSize size of the frame head, body length in big endian
+const HeadSize = headsize
+
 func int2bytes(value int) ([]byte, error) {
 	pbuffer := bytes.NewBuffer([]byte{})
 	if err := binary.Write(pbuffer, binary.BigEndian, uint32(value)); err != nil {
@@ -36,6 +42,13 @@ func bytes2int(b []byte) (int, error) {
 // package = head(4 byte) + body([]byte)
 // return []byte, error
 func Pack(serial int32, m proto.Message) ([]byte, error) {
+	return PackRequest(serial, 0, m)
+}
+
+// PackRequest params protocol id, request id, *proto.Message
+// request id is echoed back by the reply, 0 means no reply
+// return []byte, error
+func PackRequest(serial int32, request uint32, m proto.Message) ([]byte, error) {
 	var pkg Package
 	buff, err := proto.Marshal(m)
 	if err != nil {
@@ -43,6 +56,7 @@ func Pack(serial int32, m proto.Message) ([]byte, error) {
 	}
 	pkg.Serial = serial
 	pkg.Buff = buff
+	pkg.Request = request
 	body, err := proto.Marshal(&pkg)
 	if err != nil {
 		return nil, err
@@ -54,49 +68,117 @@ func Pack(serial int32, m proto.Message) ([]byte, error) {
 	return append(head, body...), nil
 }
 
-// UnPack params []byte
-// return offset, protocol id,  body([]byte)
-func UnPack(data []byte) (int, int32, []byte) {
+//MaxFrameSize default max package body size, it is not the read buffer size MaxSize
+const MaxFrameSize = 64 * 1024
+
+//unlimited body size of the deprecated functions, they never had a limit
+const unlimited = int(^uint(0) >> 1)
+
+var (
+	//ErrShortFrame need more data to unpack a whole package
+	ErrShortFrame = errors.New("protocol: short frame")
+	//ErrCorruptFrame package can not be unmarshal, connection should be closed
+	ErrCorruptFrame = errors.New("protocol: corrupt frame")
+	//ErrFrameTooLarge package head claim a body larger than the limit
+	ErrFrameTooLarge = errors.New("protocol: frame too large")
+)
+
+// UnPackFrame params []byte
+// return consumed size, protocol id, body([]byte), error
+func UnPackFrame(data []byte) (int, int32, []byte, error) {
+	return UnPackFrameSize(data, MaxFrameSize)
+}
+
+// UnPackFrameSize params []byte, max package body size
+// the limit is checked as soon as the head arrive
+// return consumed size, protocol id, body([]byte), error
+func UnPackFrameSize(data []byte, maxsize int) (int, int32, []byte, error) {
+	offset, pkg, err := UnPackPackage(data, maxsize)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return offset, pkg.Serial, pkg.Buff, nil
+}
+
+// UnPackPackage params []byte, max package body size
+// return consumed size, *Package, error
+func UnPackPackage(data []byte, maxsize int) (int, *Package, error) {
 	if len(data) < headsize {
-		return 0, 0, nil
+		return 0, nil, ErrShortFrame
 	}
 	bodysize, err := bytes2int(data[:headsize])
 	if err != nil {
-		return 0, 0, nil
+		return 0, nil, ErrCorruptFrame
+	}
+	if bodysize > maxsize {
+		return 0, nil, ErrFrameTooLarge
 	}
 	offset := headsize + bodysize
 	if len(data) < offset {
-		return 0, 0, nil
+		return 0, nil, ErrShortFrame
 	}
 	var pkg Package
 	if err := proto.Unmarshal(data[headsize:offset], &pkg); err != nil {
+		return 0, nil, ErrCorruptFrame
+	}
+	return offset, &pkg, nil
+}
+
+// UnPack params []byte
+// return offset, protocol id,  body([]byte)
+// body size is not limited, use UnPackFrameSize to refuse large frames
+// Deprecated: use UnPackFrame, an empty body can not be told apart from short data
+func UnPack(data []byte) (int, int32, []byte) {
+	offset, serial, buff, err := UnPackFrameSize(data, unlimited)
+	switch err {
+	case nil:
+		return offset, serial, buff
+	case ErrShortFrame:
+		return 0, 0, nil
+	default:
 		return 0, 0, []byte{} //data abnormal and disconnect
 	}
-	return offset, pkg.Serial, pkg.Buff
 }
 
 // Send2Client protocol id, protocol message
+// body size is not limited, use Encoder to refuse large frames
 // return error
 func Send2Client(conn net.Conn, serial S2CCmd, msg proto.Message) error {
-	buff, err := Pack(int32(serial), msg)
-	if err != nil {
-		return err
-	}
-	if _, err := conn.Write(buff); err != nil {
-		return err
-	}
-	return nil
+	return sendUnlimited(conn, int32(serial), msg)
 }
 
 // Send2Server protocol id, protocol message
+// body size is not limited, use Encoder to refuse large frames
 // return error
 func Send2Server(conn net.Conn, serial C2SCmd, msg proto.Message) error {
-	buff, err := Pack(int32(serial), msg)
-	if err != nil {
-		return err
+	return sendUnlimited(conn, int32(serial), msg)
+}
+
+func sendUnlimited(conn net.Conn, serial int32, msg proto.Message) error {
+	enc := NewEncoder(conn)
+	enc.SetMaxFrameSize(unlimited)
+	return enc.Encode(serial, msg)
+}
+
+// Unmarshal params []byte, prototype *proto.Message
+// return a new message of the same type as prototype, error
+func Unmarshal(buff []byte, prototype proto.Message) (proto.Message, error) {
+	msg := reflect.New(reflect.TypeOf(prototype).Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(buff, msg); err != nil {
+		return nil, err
 	}
-	if _, err := conn.Write(buff); err != nil {
-		return err
+	return msg, nil
+}
+
+//NewError build a request error with code
+func NewError(code ErrCode, format string, a ...interface{}) *S2CError {
+	return &S2CError{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
 	}
-	return nil
+}
+
+//Error implement error, S2CError can be return by request handle
+func (m *S2CError) Error() string {
+	return fmt.Sprintf("%s: %s", m.Code.String(), m.Message)
 }
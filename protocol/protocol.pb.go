@@ -10,7 +10,33 @@ It is generated from these files:
 It has these top-level messages:
 	Package
 	C2SChat
+	C2SReadReceipt
+	C2SPlayerList
+	C2SPing
+	C2SLogin
+	C2SRoom
+	C2SRoomList
+	C2SRoomChat
+	C2SNick
+	C2SLogout
+	S2CChat
+	OfflineMessage
+	OfflineBox
+	OfflineStore
+	OfflineRecord
+	S2CChatStatus
 	S2CResult
+	PlayerInfo
+	S2CRoster
+	S2CPresence
+	S2CWelcome
+	S2CPong
+	S2CShutdown
+	S2CLogin
+	S2CRoom
+	S2CRoomList
+	S2CRoomChat
+	S2CError
 */
 package protocol
 
@@ -33,17 +59,50 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type C2SCmd int32
 
 const (
-	C2SCmd_Abnormal C2SCmd = 0
-	C2SCmd_Chat     C2SCmd = 1
+	C2SCmd_Abnormal    C2SCmd = 0
+	C2SCmd_Chat        C2SCmd = 1
+	C2SCmd_PlayerList  C2SCmd = 2
+	C2SCmd_Ping        C2SCmd = 3
+	C2SCmd_Login       C2SCmd = 4
+	C2SCmd_RoomCreate  C2SCmd = 5
+	C2SCmd_RoomJoin    C2SCmd = 6
+	C2SCmd_RoomLeave   C2SCmd = 7
+	C2SCmd_RoomList    C2SCmd = 8
+	C2SCmd_RoomChat    C2SCmd = 9
+	C2SCmd_ReadReceipt C2SCmd = 10
+	C2SCmd_Nick        C2SCmd = 11
+	C2SCmd_Logout      C2SCmd = 12
 )
 
 var C2SCmd_name = map[int32]string{
-	0: "Abnormal",
-	1: "Chat",
+	0:  "Abnormal",
+	1:  "Chat",
+	2:  "PlayerList",
+	3:  "Ping",
+	4:  "Login",
+	5:  "RoomCreate",
+	6:  "RoomJoin",
+	7:  "RoomLeave",
+	8:  "RoomList",
+	9:  "RoomChat",
+	10: "ReadReceipt",
+	11: "Nick",
+	12: "Logout",
 }
 var C2SCmd_value = map[string]int32{
-	"Abnormal": 0,
-	"Chat":     1,
+	"Abnormal":    0,
+	"Chat":        1,
+	"PlayerList":  2,
+	"Ping":        3,
+	"Login":       4,
+	"RoomCreate":  5,
+	"RoomJoin":    6,
+	"RoomLeave":   7,
+	"RoomList":    8,
+	"RoomChat":    9,
+	"ReadReceipt": 10,
+	"Nick":        11,
+	"Logout":      12,
 }
 
 func (x C2SCmd) String() string {
@@ -55,17 +114,47 @@ func (C2SCmd) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0
 type S2CCmd int32
 
 const (
-	S2CCmd_Invalid S2CCmd = 0
-	S2CCmd_Result  S2CCmd = 1
+	S2CCmd_Invalid    S2CCmd = 0
+	S2CCmd_Result     S2CCmd = 1
+	S2CCmd_Reply      S2CCmd = 2
+	S2CCmd_Error      S2CCmd = 3
+	S2CCmd_Pong       S2CCmd = 4
+	S2CCmd_Shutdown   S2CCmd = 5
+	S2CCmd_RoomMsg    S2CCmd = 6
+	S2CCmd_Welcome    S2CCmd = 7
+	S2CCmd_Roster     S2CCmd = 8
+	S2CCmd_Presence   S2CCmd = 9
+	S2CCmd_ChatMsg    S2CCmd = 10
+	S2CCmd_ChatStatus S2CCmd = 11
 )
 
 var S2CCmd_name = map[int32]string{
-	0: "Invalid",
-	1: "Result",
+	0:  "Invalid",
+	1:  "Result",
+	2:  "Reply",
+	3:  "Error",
+	4:  "Pong",
+	5:  "Shutdown",
+	6:  "RoomMsg",
+	7:  "Welcome",
+	8:  "Roster",
+	9:  "Presence",
+	10: "ChatMsg",
+	11: "ChatStatus",
 }
 var S2CCmd_value = map[string]int32{
-	"Invalid": 0,
-	"Result":  1,
+	"Invalid":    0,
+	"Result":     1,
+	"Reply":      2,
+	"Error":      3,
+	"Pong":       4,
+	"Shutdown":   5,
+	"RoomMsg":    6,
+	"Welcome":    7,
+	"Roster":     8,
+	"Presence":   9,
+	"ChatMsg":    10,
+	"ChatStatus": 11,
 }
 
 func (x S2CCmd) String() string {
@@ -73,10 +162,112 @@ func (x S2CCmd) String() string {
 }
 func (S2CCmd) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
+// 消息状态
+type ChatState int32
+
+const (
+	ChatState_ChatUnknown   ChatState = 0
+	ChatState_ChatDelivered ChatState = 1
+	ChatState_ChatOffline   ChatState = 2
+	ChatState_ChatRejected  ChatState = 3
+	ChatState_ChatRead      ChatState = 4
+	ChatState_ChatStored    ChatState = 5
+)
+
+var ChatState_name = map[int32]string{
+	0: "ChatUnknown",
+	1: "ChatDelivered",
+	2: "ChatOffline",
+	3: "ChatRejected",
+	4: "ChatRead",
+	5: "ChatStored",
+}
+var ChatState_value = map[string]int32{
+	"ChatUnknown":   0,
+	"ChatDelivered": 1,
+	"ChatOffline":   2,
+	"ChatRejected":  3,
+	"ChatRead":      4,
+	"ChatStored":    5,
+}
+
+func (x ChatState) String() string {
+	return proto.EnumName(ChatState_name, int32(x))
+}
+func (ChatState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type OfflineOp int32
+
+const (
+	OfflineOp_OfflinePush    OfflineOp = 0
+	OfflineOp_OfflinePop     OfflineOp = 1
+	OfflineOp_OfflineRequeue OfflineOp = 2
+)
+
+var OfflineOp_name = map[int32]string{
+	0: "OfflinePush",
+	1: "OfflinePop",
+	2: "OfflineRequeue",
+}
+var OfflineOp_value = map[string]int32{
+	"OfflinePush":    0,
+	"OfflinePop":     1,
+	"OfflineRequeue": 2,
+}
+
+func (x OfflineOp) String() string {
+	return proto.EnumName(OfflineOp_name, int32(x))
+}
+func (OfflineOp) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+// 请求错误码
+type ErrCode int32
+
+const (
+	ErrCode_ErrNone       ErrCode = 0
+	ErrCode_ErrUnknownCmd ErrCode = 1
+	ErrCode_ErrBadRequest ErrCode = 2
+	ErrCode_ErrNotFound   ErrCode = 3
+	ErrCode_ErrInternal   ErrCode = 4
+	ErrCode_ErrAuthFailed ErrCode = 5
+	ErrCode_ErrNotLogin   ErrCode = 6
+	ErrCode_ErrExist      ErrCode = 7
+	ErrCode_ErrThrottled  ErrCode = 8
+)
+
+var ErrCode_name = map[int32]string{
+	0: "ErrNone",
+	1: "ErrUnknownCmd",
+	2: "ErrBadRequest",
+	3: "ErrNotFound",
+	4: "ErrInternal",
+	5: "ErrAuthFailed",
+	6: "ErrNotLogin",
+	7: "ErrExist",
+	8: "ErrThrottled",
+}
+var ErrCode_value = map[string]int32{
+	"ErrNone":       0,
+	"ErrUnknownCmd": 1,
+	"ErrBadRequest": 2,
+	"ErrNotFound":   3,
+	"ErrInternal":   4,
+	"ErrAuthFailed": 5,
+	"ErrNotLogin":   6,
+	"ErrExist":      7,
+	"ErrThrottled":  8,
+}
+
+func (x ErrCode) String() string {
+	return proto.EnumName(ErrCode_name, int32(x))
+}
+func (ErrCode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
 // Package 数据包定义
 type Package struct {
-	Serial int32  `protobuf:"varint,1,opt,name=serial" json:"serial,omitempty"`
-	Buff   []byte `protobuf:"bytes,2,opt,name=buff,proto3" json:"buff,omitempty"`
+	Serial  int32  `protobuf:"varint,1,opt,name=serial" json:"serial,omitempty"`
+	Buff    []byte `protobuf:"bytes,2,opt,name=buff,proto3" json:"buff,omitempty"`
+	Request uint32 `protobuf:"varint,3,opt,name=request" json:"request,omitempty"`
 }
 
 func (m *Package) Reset()                    { *m = Package{} }
@@ -98,9 +289,17 @@ func (m *Package) GetBuff() []byte {
 	return nil
 }
 
+func (m *Package) GetRequest() uint32 {
+	if m != nil {
+		return m.Request
+	}
+	return 0
+}
+
 type C2SChat struct {
-	Index   uint64 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
 	Context string `protobuf:"bytes,2,opt,name=context" json:"context,omitempty"`
+	MsgId   uint64 `protobuf:"varint,3,opt,name=msg_id,json=msgId" json:"msg_id,omitempty"`
+	User    string `protobuf:"bytes,4,opt,name=user" json:"user,omitempty"`
 }
 
 func (m *C2SChat) Reset()                    { *m = C2SChat{} }
@@ -108,20 +307,385 @@ func (m *C2SChat) String() string            { return proto.CompactTextString(m)
 func (*C2SChat) ProtoMessage()               {}
 func (*C2SChat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
-func (m *C2SChat) GetIndex() uint64 {
+func (m *C2SChat) GetContext() string {
+	if m != nil {
+		return m.Context
+	}
+	return ""
+}
+
+func (m *C2SChat) GetMsgId() uint64 {
 	if m != nil {
-		return m.Index
+		return m.MsgId
 	}
 	return 0
 }
 
-func (m *C2SChat) GetContext() string {
+func (m *C2SChat) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+type C2SReadReceipt struct {
+	MsgId uint64 `protobuf:"varint,2,opt,name=msg_id,json=msgId" json:"msg_id,omitempty"`
+	From  string `protobuf:"bytes,3,opt,name=from" json:"from,omitempty"`
+}
+
+func (m *C2SReadReceipt) Reset()                    { *m = C2SReadReceipt{} }
+func (m *C2SReadReceipt) String() string            { return proto.CompactTextString(m) }
+func (*C2SReadReceipt) ProtoMessage()               {}
+func (*C2SReadReceipt) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *C2SReadReceipt) GetMsgId() uint64 {
+	if m != nil {
+		return m.MsgId
+	}
+	return 0
+}
+
+func (m *C2SReadReceipt) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+type C2SPlayerList struct {
+	After string `protobuf:"bytes,1,opt,name=after" json:"after,omitempty"`
+}
+
+func (m *C2SPlayerList) Reset()                    { *m = C2SPlayerList{} }
+func (m *C2SPlayerList) String() string            { return proto.CompactTextString(m) }
+func (*C2SPlayerList) ProtoMessage()               {}
+func (*C2SPlayerList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *C2SPlayerList) GetAfter() string {
+	if m != nil {
+		return m.After
+	}
+	return ""
+}
+
+type C2SPing struct {
+	Time int64 `protobuf:"varint,1,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *C2SPing) Reset()                    { *m = C2SPing{} }
+func (m *C2SPing) String() string            { return proto.CompactTextString(m) }
+func (*C2SPing) ProtoMessage()               {}
+func (*C2SPing) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *C2SPing) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+// 用户名为空时使用token以游客登录, 同一个token总是得到同一个用户ID
+type C2SLogin struct {
+	Username   string `protobuf:"bytes,1,opt,name=username" json:"username,omitempty"`
+	Credential string `protobuf:"bytes,2,opt,name=credential" json:"credential,omitempty"`
+	Token      string `protobuf:"bytes,3,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *C2SLogin) Reset()                    { *m = C2SLogin{} }
+func (m *C2SLogin) String() string            { return proto.CompactTextString(m) }
+func (*C2SLogin) ProtoMessage()               {}
+func (*C2SLogin) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *C2SLogin) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *C2SLogin) GetCredential() string {
+	if m != nil {
+		return m.Credential
+	}
+	return ""
+}
+
+func (m *C2SLogin) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+// 创建, 加入, 离开房间
+type C2SRoom struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *C2SRoom) Reset()                    { *m = C2SRoom{} }
+func (m *C2SRoom) String() string            { return proto.CompactTextString(m) }
+func (*C2SRoom) ProtoMessage()               {}
+func (*C2SRoom) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *C2SRoom) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type C2SRoomList struct {
+}
+
+func (m *C2SRoomList) Reset()                    { *m = C2SRoomList{} }
+func (m *C2SRoomList) String() string            { return proto.CompactTextString(m) }
+func (*C2SRoomList) ProtoMessage()               {}
+func (*C2SRoomList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+type C2SRoomChat struct {
+	Room    string `protobuf:"bytes,1,opt,name=room" json:"room,omitempty"`
+	Context string `protobuf:"bytes,2,opt,name=context" json:"context,omitempty"`
+}
+
+func (m *C2SRoomChat) Reset()                    { *m = C2SRoomChat{} }
+func (m *C2SRoomChat) String() string            { return proto.CompactTextString(m) }
+func (*C2SRoomChat) ProtoMessage()               {}
+func (*C2SRoomChat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *C2SRoomChat) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *C2SRoomChat) GetContext() string {
+	if m != nil {
+		return m.Context
+	}
+	return ""
+}
+
+// 修改显示名, 回复PlayerInfo, 其他玩家收到上线的Presence
+type C2SNick struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *C2SNick) Reset()                    { *m = C2SNick{} }
+func (m *C2SNick) String() string            { return proto.CompactTextString(m) }
+func (*C2SNick) ProtoMessage()               {}
+func (*C2SNick) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *C2SNick) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type C2SLogout struct {
+}
+
+func (m *C2SLogout) Reset()                    { *m = C2SLogout{} }
+func (m *C2SLogout) String() string            { return proto.CompactTextString(m) }
+func (*C2SLogout) ProtoMessage()               {}
+func (*C2SLogout) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type S2CChat struct {
+	Context string `protobuf:"bytes,2,opt,name=context" json:"context,omitempty"`
+	MsgId   uint64 `protobuf:"varint,3,opt,name=msg_id,json=msgId" json:"msg_id,omitempty"`
+	From    string `protobuf:"bytes,4,opt,name=from" json:"from,omitempty"`
+	Time    int64  `protobuf:"varint,5,opt,name=time" json:"time,omitempty"`
+	Offline bool   `protobuf:"varint,6,opt,name=offline" json:"offline,omitempty"`
+}
+
+func (m *S2CChat) Reset()                    { *m = S2CChat{} }
+func (m *S2CChat) String() string            { return proto.CompactTextString(m) }
+func (*S2CChat) ProtoMessage()               {}
+func (*S2CChat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *S2CChat) GetContext() string {
 	if m != nil {
 		return m.Context
 	}
 	return ""
 }
 
+func (m *S2CChat) GetMsgId() uint64 {
+	if m != nil {
+		return m.MsgId
+	}
+	return 0
+}
+
+func (m *S2CChat) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *S2CChat) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *S2CChat) GetOffline() bool {
+	if m != nil {
+		return m.Offline
+	}
+	return false
+}
+
+// 离线消息存储
+type OfflineMessage struct {
+	From    string `protobuf:"bytes,1,opt,name=from" json:"from,omitempty"`
+	Context string `protobuf:"bytes,2,opt,name=context" json:"context,omitempty"`
+	MsgId   uint64 `protobuf:"varint,3,opt,name=msg_id,json=msgId" json:"msg_id,omitempty"`
+	Time    int64  `protobuf:"varint,4,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *OfflineMessage) Reset()                    { *m = OfflineMessage{} }
+func (m *OfflineMessage) String() string            { return proto.CompactTextString(m) }
+func (*OfflineMessage) ProtoMessage()               {}
+func (*OfflineMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *OfflineMessage) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *OfflineMessage) GetContext() string {
+	if m != nil {
+		return m.Context
+	}
+	return ""
+}
+
+func (m *OfflineMessage) GetMsgId() uint64 {
+	if m != nil {
+		return m.MsgId
+	}
+	return 0
+}
+
+func (m *OfflineMessage) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type OfflineBox struct {
+	Messages []*OfflineMessage `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *OfflineBox) Reset()                    { *m = OfflineBox{} }
+func (m *OfflineBox) String() string            { return proto.CompactTextString(m) }
+func (*OfflineBox) ProtoMessage()               {}
+func (*OfflineBox) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *OfflineBox) GetMessages() []*OfflineMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+// 旧版离线文件, 整个文件一条消息, 加载后转为日志
+type OfflineStore struct {
+	Boxes map[string]*OfflineBox `protobuf:"bytes,1,rep,name=boxes" json:"boxes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *OfflineStore) Reset()                    { *m = OfflineStore{} }
+func (m *OfflineStore) String() string            { return proto.CompactTextString(m) }
+func (*OfflineStore) ProtoMessage()               {}
+func (*OfflineStore) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *OfflineStore) GetBoxes() map[string]*OfflineBox {
+	if m != nil {
+		return m.Boxes
+	}
+	return nil
+}
+
+// 离线消息日志的一条记录, 文件为 4字节长度(大端) + 记录 逐条追加
+type OfflineRecord struct {
+	Op       OfflineOp         `protobuf:"varint,1,opt,name=op,enum=protocol.OfflineOp" json:"op,omitempty"`
+	User     string            `protobuf:"bytes,2,opt,name=user" json:"user,omitempty"`
+	Messages []*OfflineMessage `protobuf:"bytes,3,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *OfflineRecord) Reset()                    { *m = OfflineRecord{} }
+func (m *OfflineRecord) String() string            { return proto.CompactTextString(m) }
+func (*OfflineRecord) ProtoMessage()               {}
+func (*OfflineRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *OfflineRecord) GetOp() OfflineOp {
+	if m != nil {
+		return m.Op
+	}
+	return OfflineOp_OfflinePush
+}
+
+func (m *OfflineRecord) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *OfflineRecord) GetMessages() []*OfflineMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type S2CChatStatus struct {
+	MsgId  uint64    `protobuf:"varint,1,opt,name=msg_id,json=msgId" json:"msg_id,omitempty"`
+	State  ChatState `protobuf:"varint,3,opt,name=state,enum=protocol.ChatState" json:"state,omitempty"`
+	Reason string    `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+	Target string    `protobuf:"bytes,5,opt,name=target" json:"target,omitempty"`
+}
+
+func (m *S2CChatStatus) Reset()                    { *m = S2CChatStatus{} }
+func (m *S2CChatStatus) String() string            { return proto.CompactTextString(m) }
+func (*S2CChatStatus) ProtoMessage()               {}
+func (*S2CChatStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *S2CChatStatus) GetMsgId() uint64 {
+	if m != nil {
+		return m.MsgId
+	}
+	return 0
+}
+
+func (m *S2CChatStatus) GetState() ChatState {
+	if m != nil {
+		return m.State
+	}
+	return ChatState_ChatUnknown
+}
+
+func (m *S2CChatStatus) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *S2CChatStatus) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
 type S2CResult struct {
 	Context string `protobuf:"bytes,1,opt,name=context" json:"context,omitempty"`
 }
@@ -129,7 +693,7 @@ type S2CResult struct {
 func (m *S2CResult) Reset()                    { *m = S2CResult{} }
 func (m *S2CResult) String() string            { return proto.CompactTextString(m) }
 func (*S2CResult) ProtoMessage()               {}
-func (*S2CResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*S2CResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *S2CResult) GetContext() string {
 	if m != nil {
@@ -138,30 +702,410 @@ func (m *S2CResult) GetContext() string {
 	return ""
 }
 
+// 玩家信息
+type PlayerInfo struct {
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Id   string `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *PlayerInfo) Reset()                    { *m = PlayerInfo{} }
+func (m *PlayerInfo) String() string            { return proto.CompactTextString(m) }
+func (*PlayerInfo) ProtoMessage()               {}
+func (*PlayerInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *PlayerInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PlayerInfo) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// 玩家列表, 按ID排序分页, 每页不超过最大帧, 登录时只推送第一页
+type S2CRoster struct {
+	Players []*PlayerInfo `protobuf:"bytes,1,rep,name=players" json:"players,omitempty"`
+	More    bool          `protobuf:"varint,2,opt,name=more" json:"more,omitempty"`
+	Total   int32         `protobuf:"varint,3,opt,name=total" json:"total,omitempty"`
+}
+
+func (m *S2CRoster) Reset()                    { *m = S2CRoster{} }
+func (m *S2CRoster) String() string            { return proto.CompactTextString(m) }
+func (*S2CRoster) ProtoMessage()               {}
+func (*S2CRoster) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *S2CRoster) GetPlayers() []*PlayerInfo {
+	if m != nil {
+		return m.Players
+	}
+	return nil
+}
+
+func (m *S2CRoster) GetMore() bool {
+	if m != nil {
+		return m.More
+	}
+	return false
+}
+
+func (m *S2CRoster) GetTotal() int32 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+// 玩家上下线
+type S2CPresence struct {
+	Player *PlayerInfo `protobuf:"bytes,1,opt,name=player" json:"player,omitempty"`
+	Online bool        `protobuf:"varint,2,opt,name=online" json:"online,omitempty"`
+}
+
+func (m *S2CPresence) Reset()                    { *m = S2CPresence{} }
+func (m *S2CPresence) String() string            { return proto.CompactTextString(m) }
+func (*S2CPresence) ProtoMessage()               {}
+func (*S2CPresence) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *S2CPresence) GetPlayer() *PlayerInfo {
+	if m != nil {
+		return m.Player
+	}
+	return nil
+}
+
+func (m *S2CPresence) GetOnline() bool {
+	if m != nil {
+		return m.Online
+	}
+	return false
+}
+
+// 登录成功后发送
+type S2CWelcome struct {
+	Self       *PlayerInfo `protobuf:"bytes,1,opt,name=self" json:"self,omitempty"`
+	ServerName string      `protobuf:"bytes,2,opt,name=server_name,json=serverName" json:"server_name,omitempty"`
+	Version    string      `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
+	Time       int64       `protobuf:"varint,4,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *S2CWelcome) Reset()                    { *m = S2CWelcome{} }
+func (m *S2CWelcome) String() string            { return proto.CompactTextString(m) }
+func (*S2CWelcome) ProtoMessage()               {}
+func (*S2CWelcome) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *S2CWelcome) GetSelf() *PlayerInfo {
+	if m != nil {
+		return m.Self
+	}
+	return nil
+}
+
+func (m *S2CWelcome) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *S2CWelcome) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *S2CWelcome) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type S2CPong struct {
+	Time int64 `protobuf:"varint,1,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *S2CPong) Reset()                    { *m = S2CPong{} }
+func (m *S2CPong) String() string            { return proto.CompactTextString(m) }
+func (*S2CPong) ProtoMessage()               {}
+func (*S2CPong) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *S2CPong) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type S2CShutdown struct {
+	Reason string `protobuf:"bytes,1,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *S2CShutdown) Reset()                    { *m = S2CShutdown{} }
+func (m *S2CShutdown) String() string            { return proto.CompactTextString(m) }
+func (*S2CShutdown) ProtoMessage()               {}
+func (*S2CShutdown) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *S2CShutdown) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type S2CLogin struct {
+	Id      string `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+	Resumed bool   `protobuf:"varint,3,opt,name=resumed" json:"resumed,omitempty"`
+}
+
+func (m *S2CLogin) Reset()                    { *m = S2CLogin{} }
+func (m *S2CLogin) String() string            { return proto.CompactTextString(m) }
+func (*S2CLogin) ProtoMessage()               {}
+func (*S2CLogin) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *S2CLogin) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *S2CLogin) GetResumed() bool {
+	if m != nil {
+		return m.Resumed
+	}
+	return false
+}
+
+type S2CRoom struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Members []string `protobuf:"bytes,3,rep,name=members" json:"members,omitempty"`
+}
+
+func (m *S2CRoom) Reset()                    { *m = S2CRoom{} }
+func (m *S2CRoom) String() string            { return proto.CompactTextString(m) }
+func (*S2CRoom) ProtoMessage()               {}
+func (*S2CRoom) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *S2CRoom) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *S2CRoom) GetMembers() []string {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+type S2CRoomList struct {
+	Rooms []*S2CRoom `protobuf:"bytes,1,rep,name=rooms" json:"rooms,omitempty"`
+}
+
+func (m *S2CRoomList) Reset()                    { *m = S2CRoomList{} }
+func (m *S2CRoomList) String() string            { return proto.CompactTextString(m) }
+func (*S2CRoomList) ProtoMessage()               {}
+func (*S2CRoomList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *S2CRoomList) GetRooms() []*S2CRoom {
+	if m != nil {
+		return m.Rooms
+	}
+	return nil
+}
+
+type S2CRoomChat struct {
+	Room    string `protobuf:"bytes,1,opt,name=room" json:"room,omitempty"`
+	Context string `protobuf:"bytes,3,opt,name=context" json:"context,omitempty"`
+	From    string `protobuf:"bytes,4,opt,name=from" json:"from,omitempty"`
+}
+
+func (m *S2CRoomChat) Reset()                    { *m = S2CRoomChat{} }
+func (m *S2CRoomChat) String() string            { return proto.CompactTextString(m) }
+func (*S2CRoomChat) ProtoMessage()               {}
+func (*S2CRoomChat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *S2CRoomChat) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *S2CRoomChat) GetContext() string {
+	if m != nil {
+		return m.Context
+	}
+	return ""
+}
+
+func (m *S2CRoomChat) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+// 请求的错误, ErrThrottled或房间聊天失败时不需要回复的协议也会收到
+type S2CError struct {
+	Code       ErrCode `protobuf:"varint,1,opt,name=code,enum=protocol.ErrCode" json:"code,omitempty"`
+	Message    string  `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+	RetryAfter int64   `protobuf:"varint,3,opt,name=retry_after,json=retryAfter" json:"retry_after,omitempty"`
+}
+
+func (m *S2CError) Reset()                    { *m = S2CError{} }
+func (m *S2CError) String() string            { return proto.CompactTextString(m) }
+func (*S2CError) ProtoMessage()               {}
+func (*S2CError) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *S2CError) GetCode() ErrCode {
+	if m != nil {
+		return m.Code
+	}
+	return ErrCode_ErrNone
+}
+
+func (m *S2CError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *S2CError) GetRetryAfter() int64 {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Package)(nil), "protocol.Package")
 	proto.RegisterType((*C2SChat)(nil), "protocol.C2SChat")
+	proto.RegisterType((*C2SReadReceipt)(nil), "protocol.C2SReadReceipt")
+	proto.RegisterType((*C2SPlayerList)(nil), "protocol.C2SPlayerList")
+	proto.RegisterType((*C2SPing)(nil), "protocol.C2SPing")
+	proto.RegisterType((*C2SLogin)(nil), "protocol.C2SLogin")
+	proto.RegisterType((*C2SRoom)(nil), "protocol.C2SRoom")
+	proto.RegisterType((*C2SRoomList)(nil), "protocol.C2SRoomList")
+	proto.RegisterType((*C2SRoomChat)(nil), "protocol.C2SRoomChat")
+	proto.RegisterType((*C2SNick)(nil), "protocol.C2SNick")
+	proto.RegisterType((*C2SLogout)(nil), "protocol.C2SLogout")
+	proto.RegisterType((*S2CChat)(nil), "protocol.S2CChat")
+	proto.RegisterType((*OfflineMessage)(nil), "protocol.OfflineMessage")
+	proto.RegisterType((*OfflineBox)(nil), "protocol.OfflineBox")
+	proto.RegisterType((*OfflineStore)(nil), "protocol.OfflineStore")
+	proto.RegisterType((*OfflineRecord)(nil), "protocol.OfflineRecord")
+	proto.RegisterType((*S2CChatStatus)(nil), "protocol.S2CChatStatus")
 	proto.RegisterType((*S2CResult)(nil), "protocol.S2CResult")
+	proto.RegisterType((*PlayerInfo)(nil), "protocol.PlayerInfo")
+	proto.RegisterType((*S2CRoster)(nil), "protocol.S2CRoster")
+	proto.RegisterType((*S2CPresence)(nil), "protocol.S2CPresence")
+	proto.RegisterType((*S2CWelcome)(nil), "protocol.S2CWelcome")
+	proto.RegisterType((*S2CPong)(nil), "protocol.S2CPong")
+	proto.RegisterType((*S2CShutdown)(nil), "protocol.S2CShutdown")
+	proto.RegisterType((*S2CLogin)(nil), "protocol.S2CLogin")
+	proto.RegisterType((*S2CRoom)(nil), "protocol.S2CRoom")
+	proto.RegisterType((*S2CRoomList)(nil), "protocol.S2CRoomList")
+	proto.RegisterType((*S2CRoomChat)(nil), "protocol.S2CRoomChat")
+	proto.RegisterType((*S2CError)(nil), "protocol.S2CError")
 	proto.RegisterEnum("protocol.C2SCmd", C2SCmd_name, C2SCmd_value)
 	proto.RegisterEnum("protocol.S2CCmd", S2CCmd_name, S2CCmd_value)
+	proto.RegisterEnum("protocol.ChatState", ChatState_name, ChatState_value)
+	proto.RegisterEnum("protocol.OfflineOp", OfflineOp_name, OfflineOp_value)
+	proto.RegisterEnum("protocol.ErrCode", ErrCode_name, ErrCode_value)
 }
 
 func init() { proto.RegisterFile("protocol.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 210 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0x41, 0x4b, 0x85, 0x40,
-	0x14, 0x85, 0xdf, 0x84, 0x6f, 0xc6, 0x77, 0x7b, 0xc4, 0x70, 0x89, 0x70, 0x69, 0x42, 0x20, 0x2e,
-	0x5a, 0x18, 0x2d, 0x5a, 0xc6, 0xac, 0xda, 0xc5, 0xf8, 0x0b, 0x46, 0x1d, 0x4b, 0x1a, 0x67, 0x42,
-	0xc7, 0xf0, 0xe7, 0x87, 0x63, 0x42, 0x6f, 0x77, 0x3e, 0xf8, 0xce, 0x3d, 0x5c, 0xb8, 0xf9, 0x1e,
-	0x9d, 0x77, 0x8d, 0x33, 0x8f, 0x21, 0x60, 0xbc, 0x73, 0xf6, 0x0c, 0xec, 0x5d, 0x35, 0x5f, 0xea,
-	0x43, 0xe3, 0x1d, 0xd0, 0x49, 0x8f, 0xbd, 0x32, 0x09, 0x49, 0x49, 0x7e, 0x94, 0x7f, 0x84, 0x08,
-	0x51, 0x3d, 0x77, 0x5d, 0x72, 0x95, 0x92, 0xfc, 0x2c, 0x43, 0xce, 0x5e, 0x80, 0x89, 0xb2, 0x12,
-	0x9f, 0xca, 0xe3, 0x2d, 0x1c, 0x7b, 0xdb, 0xea, 0x25, 0xb4, 0x22, 0xb9, 0x01, 0x26, 0xc0, 0x1a,
-	0x67, 0xbd, 0x5e, 0x7c, 0xe8, 0x9d, 0xe4, 0x8e, 0xd9, 0x03, 0x9c, 0xaa, 0x52, 0x48, 0x3d, 0xcd,
-	0xc6, 0xff, 0xd7, 0xc8, 0x85, 0x56, 0xa4, 0x40, 0xd7, 0x85, 0xa1, 0xc5, 0x33, 0xc4, 0xaf, 0xb5,
-	0x75, 0xe3, 0xa0, 0x0c, 0x3f, 0x60, 0x0c, 0xd1, 0x3a, 0xcb, 0x49, 0x71, 0x0f, 0xb4, 0x2a, 0xc5,
-	0x6a, 0x5c, 0x03, 0x7b, 0xb3, 0x3f, 0xca, 0xf4, 0x2d, 0x3f, 0x20, 0x00, 0xdd, 0x8e, 0x73, 0x52,
-	0xd3, 0xf0, 0xe7, 0xd3, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x11, 0x67, 0x34, 0xe5, 0x00, 0x01,
-	0x00, 0x00,
+	// 1273 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x4f, 0x6f, 0xdb, 0xc6,
+	0x12, 0x37, 0x29, 0x4a, 0x22, 0x47, 0x96, 0xdf, 0x66, 0x5f, 0xde, 0x83, 0x10, 0x20, 0xef, 0xb9,
+	0x2c, 0x8c, 0xba, 0x46, 0xe1, 0x83, 0x5a, 0xa4, 0x45, 0x8a, 0x02, 0xb5, 0x55, 0x05, 0x70, 0x90,
+	0x38, 0xc6, 0x2a, 0x41, 0x2f, 0x05, 0x02, 0x5a, 0x1c, 0xc9, 0xac, 0x49, 0xae, 0xba, 0x5c, 0xba,
+	0xf6, 0xa5, 0x87, 0x02, 0x45, 0x3f, 0x42, 0xaf, 0x45, 0xbf, 0x43, 0xbf, 0x5f, 0x31, 0xbb, 0x4b,
+	0x89, 0x6e, 0xe2, 0x22, 0xc8, 0x6d, 0x67, 0x76, 0xe6, 0x37, 0xff, 0x67, 0x17, 0x76, 0x56, 0x4a,
+	0x6a, 0x39, 0x97, 0xf9, 0xa1, 0x39, 0xf0, 0xb0, 0xa1, 0xe3, 0x17, 0xd0, 0x3f, 0x4b, 0xe6, 0x97,
+	0xc9, 0x12, 0xf9, 0x7f, 0xa1, 0x57, 0xa1, 0xca, 0x92, 0x7c, 0xe4, 0xed, 0x7a, 0xfb, 0x5d, 0xe1,
+	0x28, 0xce, 0x21, 0x38, 0xaf, 0x17, 0x8b, 0x91, 0xbf, 0xeb, 0xed, 0x6f, 0x0b, 0x73, 0xe6, 0x23,
+	0xe8, 0x2b, 0xfc, 0xa1, 0xc6, 0x4a, 0x8f, 0x3a, 0xbb, 0xde, 0xfe, 0x50, 0x34, 0x64, 0xfc, 0x12,
+	0xfa, 0x93, 0xf1, 0x6c, 0x72, 0x91, 0x68, 0x12, 0x9a, 0xcb, 0x52, 0xe3, 0xb5, 0x36, 0xba, 0x91,
+	0x68, 0x48, 0xfe, 0x1f, 0xe8, 0x15, 0xd5, 0xf2, 0x75, 0x96, 0x1a, 0xed, 0x40, 0x74, 0x8b, 0x6a,
+	0x79, 0x92, 0x92, 0xa5, 0xba, 0x42, 0x35, 0x0a, 0x8c, 0xb4, 0x39, 0x3f, 0x0d, 0x42, 0x8f, 0xf9,
+	0xf1, 0x11, 0xec, 0x4c, 0xc6, 0x33, 0x81, 0x49, 0x2a, 0x70, 0x8e, 0xd9, 0xaa, 0x0d, 0xe1, 0xff,
+	0x0d, 0x62, 0xa1, 0x64, 0x61, 0x70, 0x23, 0x61, 0xce, 0x0e, 0x62, 0x0f, 0x86, 0x93, 0xf1, 0xec,
+	0x2c, 0x4f, 0x6e, 0x50, 0x3d, 0xcb, 0x2a, 0xcd, 0xef, 0x43, 0x37, 0x59, 0x68, 0x54, 0x26, 0xdc,
+	0x48, 0x58, 0x22, 0x7e, 0x68, 0xfc, 0x3f, 0xcb, 0xca, 0x25, 0x61, 0xe9, 0xac, 0x40, 0x73, 0xdf,
+	0x11, 0xe6, 0x1c, 0x7f, 0x07, 0xe1, 0x64, 0x3c, 0x7b, 0x26, 0x97, 0x59, 0xc9, 0x1f, 0x40, 0x48,
+	0x2e, 0x96, 0x89, 0x93, 0x89, 0xc4, 0x9a, 0xe6, 0xff, 0x03, 0x98, 0x2b, 0x4c, 0xb1, 0xd4, 0x94,
+	0x50, 0x1b, 0x7e, 0x8b, 0x43, 0xc6, 0xb5, 0xbc, 0xc4, 0xd2, 0x39, 0x6a, 0x09, 0x67, 0x5c, 0x48,
+	0x59, 0x90, 0xf1, 0x16, 0xb0, 0x39, 0xc7, 0x43, 0x18, 0xb8, 0x6b, 0x0a, 0x20, 0xfe, 0x72, 0x4d,
+	0x9a, 0x74, 0x73, 0x08, 0x94, 0x94, 0x45, 0xa3, 0x41, 0xe7, 0xbb, 0x4b, 0xe0, 0x4c, 0x9d, 0x66,
+	0xf3, 0xcb, 0xb7, 0x9a, 0x1a, 0x40, 0x64, 0xe3, 0x94, 0xb5, 0x8e, 0x7f, 0xf6, 0xa0, 0x3f, 0x1b,
+	0x4f, 0xde, 0xbb, 0xa8, 0xa6, 0x22, 0xc1, 0xa6, 0x22, 0xeb, 0xcc, 0x76, 0x37, 0x99, 0x25, 0x60,
+	0xb9, 0x58, 0xe4, 0x59, 0x89, 0xa3, 0xde, 0xae, 0xb7, 0x1f, 0x8a, 0x86, 0x74, 0xf5, 0xcb, 0x60,
+	0xe7, 0x85, 0x65, 0x3c, 0xc7, 0xaa, 0xa2, 0x86, 0x6d, 0x90, 0xbd, 0x16, 0xf2, 0xfb, 0xb8, 0x67,
+	0x5c, 0x09, 0x5a, 0x45, 0x3e, 0x06, 0x70, 0xa6, 0x8e, 0xe5, 0x35, 0xff, 0x0c, 0xc2, 0xc2, 0x5a,
+	0xac, 0x46, 0xde, 0x6e, 0x67, 0x7f, 0x30, 0x1e, 0x1d, 0xae, 0xe7, 0xe9, 0xb6, 0x4b, 0x62, 0x2d,
+	0x19, 0xff, 0xe6, 0xc1, 0xb6, 0xbb, 0x9c, 0x69, 0xa9, 0x90, 0x7f, 0x0e, 0xdd, 0x73, 0x79, 0xbd,
+	0xc6, 0xf8, 0xe0, 0x0d, 0x0c, 0x23, 0x76, 0x78, 0x4c, 0x32, 0xd3, 0x52, 0xab, 0x1b, 0x61, 0xe5,
+	0x1f, 0x9c, 0x02, 0x6c, 0x98, 0x9c, 0x41, 0xe7, 0x12, 0x6f, 0x5c, 0xcc, 0x74, 0xe4, 0x07, 0xd0,
+	0xbd, 0x4a, 0xf2, 0x1a, 0x4d, 0xc0, 0x83, 0xf1, 0xfd, 0x37, 0x80, 0x8f, 0xe5, 0xb5, 0xb0, 0x22,
+	0x8f, 0xfd, 0x2f, 0xbc, 0xf8, 0x27, 0x18, 0xba, 0x0b, 0x81, 0x73, 0xa9, 0x52, 0xfe, 0x21, 0xf8,
+	0x72, 0x65, 0x10, 0x77, 0xc6, 0xff, 0x7e, 0x43, 0xfb, 0xc5, 0x4a, 0xf8, 0x72, 0xb5, 0x9e, 0x4d,
+	0x7f, 0x33, 0x9b, 0xb7, 0x32, 0xd3, 0x79, 0xe7, 0xcc, 0xfc, 0xea, 0xc1, 0xd0, 0x75, 0xd3, 0x4c,
+	0x27, 0xba, 0xae, 0x5a, 0xa5, 0xf1, 0xda, 0xa5, 0xf9, 0x18, 0xba, 0x95, 0x4e, 0x34, 0x9a, 0x82,
+	0xdd, 0x72, 0xad, 0xd1, 0x45, 0x61, 0x25, 0x68, 0x77, 0x29, 0x4c, 0x2a, 0x59, 0xba, 0x36, 0x73,
+	0x14, 0xf1, 0x75, 0xa2, 0x96, 0xa8, 0x4d, 0xab, 0x45, 0xc2, 0x51, 0x4f, 0x83, 0xd0, 0x67, 0x9d,
+	0x78, 0x0f, 0xa2, 0xd9, 0x78, 0x22, 0xb0, 0xaa, 0xf3, 0x5b, 0x8d, 0xed, 0xdd, 0x1e, 0x95, 0x47,
+	0x00, 0x76, 0x6d, 0x9c, 0x94, 0x0b, 0xb9, 0x9e, 0x16, 0x7f, 0x33, 0x2d, 0x7c, 0x07, 0x7c, 0xd7,
+	0x57, 0x91, 0xf0, 0xb3, 0xd4, 0x75, 0x2c, 0x5a, 0x78, 0x59, 0x69, 0x54, 0xfc, 0x10, 0xfa, 0x2b,
+	0x03, 0xd2, 0x34, 0x40, 0xab, 0x4e, 0x1b, 0x74, 0xd1, 0x08, 0x91, 0x99, 0x42, 0x2a, 0x6b, 0x26,
+	0x14, 0xe6, 0x6c, 0x97, 0x86, 0x4e, 0x72, 0x63, 0xa9, 0x2b, 0x2c, 0x11, 0xcf, 0x60, 0x30, 0x1b,
+	0x4f, 0xce, 0x14, 0x56, 0x58, 0xce, 0x91, 0x7f, 0x02, 0x3d, 0x8b, 0x61, 0xc2, 0xb8, 0xcb, 0x8e,
+	0x93, 0xa1, 0x04, 0xc9, 0xd2, 0x0c, 0x9d, 0x35, 0xe4, 0xa8, 0xf8, 0x17, 0x0f, 0x60, 0x36, 0x9e,
+	0x7c, 0x8b, 0xf9, 0x5c, 0x16, 0xc8, 0xf7, 0x21, 0xa8, 0x30, 0x5f, 0xfc, 0x23, 0xa4, 0x91, 0xe0,
+	0xff, 0x87, 0x41, 0x85, 0xea, 0x0a, 0xd5, 0xeb, 0x56, 0x96, 0xc0, 0xb2, 0x4e, 0x13, 0x3b, 0xe7,
+	0x57, 0xa8, 0xaa, 0x4c, 0x36, 0xbb, 0xaf, 0x21, 0xdf, 0x3a, 0x8a, 0x0f, 0xcd, 0xe6, 0x39, 0x93,
+	0x77, 0xac, 0xe3, 0x3d, 0x13, 0xfb, 0xec, 0xa2, 0xd6, 0xa9, 0xfc, 0xb1, 0x6c, 0xb5, 0x81, 0xd7,
+	0x6e, 0x83, 0xf8, 0x31, 0x84, 0xb3, 0xf1, 0xc4, 0x6e, 0x6d, 0x5b, 0x2b, 0xbf, 0xa9, 0x95, 0x7d,
+	0xca, 0xaa, 0xba, 0x40, 0x5b, 0xc0, 0x50, 0x34, 0xa4, 0xab, 0xe2, 0x57, 0xc6, 0x83, 0xbb, 0x76,
+	0x32, 0xa9, 0x17, 0x58, 0x9c, 0x53, 0x5d, 0x69, 0x04, 0x22, 0xd1, 0x90, 0xae, 0xc7, 0x1e, 0x19,
+	0x0f, 0x9b, 0x9d, 0xcd, 0x3f, 0x82, 0x2e, 0x2d, 0xe6, 0xa6, 0x09, 0xee, 0x6d, 0x32, 0xe9, 0xa4,
+	0x84, 0xbd, 0x8f, 0x5f, 0xad, 0xf5, 0xde, 0x65, 0xb9, 0x77, 0x6e, 0xef, 0xba, 0xb7, 0xec, 0x5c,
+	0xe7, 0x4e, 0x6e, 0x32, 0x31, 0x55, 0x4a, 0x2a, 0xbe, 0x07, 0xc1, 0x5c, 0xa6, 0xe8, 0x26, 0xbf,
+	0xe5, 0xca, 0x54, 0xa9, 0x89, 0x4c, 0x51, 0x98, 0x6b, 0x1b, 0xa1, 0x99, 0xdd, 0x66, 0xa5, 0x3a,
+	0x92, 0x6a, 0xad, 0x50, 0xab, 0x9b, 0xd7, 0xf6, 0x1d, 0xed, 0x98, 0xc2, 0x80, 0x61, 0x1d, 0x11,
+	0xe7, 0xe0, 0x4f, 0x0f, 0x7a, 0xf4, 0x1b, 0x28, 0x52, 0xbe, 0x0d, 0xe1, 0xd1, 0x79, 0x29, 0x55,
+	0x91, 0xe4, 0x6c, 0x8b, 0x87, 0x10, 0x50, 0x58, 0xcc, 0xe3, 0x3b, 0xcd, 0x70, 0x51, 0x7a, 0x98,
+	0x4f, 0x37, 0xf4, 0xf8, 0xb2, 0x0e, 0x8f, 0xa0, 0x6b, 0x2a, 0xc6, 0x02, 0x12, 0x32, 0x99, 0x50,
+	0x98, 0x68, 0x64, 0x5d, 0x02, 0x23, 0xfa, 0xa9, 0xcc, 0x4a, 0xd6, 0xe3, 0x43, 0x88, 0x4c, 0x7e,
+	0x31, 0xb9, 0x42, 0xd6, 0x6f, 0x2e, 0x0d, 0x5e, 0xd8, 0x50, 0xc6, 0x5a, 0xc4, 0xff, 0x05, 0x83,
+	0xd6, 0x27, 0x82, 0x01, 0x99, 0xa3, 0x37, 0x90, 0x0d, 0x38, 0x40, 0xcf, 0x3e, 0x77, 0x6c, 0xfb,
+	0xe0, 0x77, 0x0f, 0x7a, 0xb4, 0xa2, 0x8a, 0x94, 0x0f, 0xa0, 0x7f, 0x52, 0x5e, 0x25, 0x79, 0x96,
+	0xb2, 0x2d, 0x92, 0xb1, 0xdb, 0x82, 0x79, 0xe4, 0x9e, 0xc0, 0x55, 0x7e, 0xc3, 0x7c, 0x3a, 0x9a,
+	0x8c, 0xb2, 0x8e, 0x71, 0x5f, 0x96, 0x4b, 0x16, 0x90, 0xe1, 0xa6, 0x2f, 0x59, 0x97, 0x60, 0xc8,
+	0x8d, 0xe7, 0xd5, 0x92, 0xf5, 0x88, 0x70, 0x83, 0xc5, 0xfa, 0x06, 0xd3, 0xac, 0x08, 0xeb, 0x6c,
+	0x33, 0xc7, 0x2c, 0x22, 0x31, 0x72, 0x9b, 0x74, 0x80, 0x52, 0xb0, 0xd9, 0x98, 0x6c, 0x70, 0xb0,
+	0x82, 0x68, 0xbd, 0x05, 0x29, 0x2c, 0x22, 0x5e, 0x95, 0x97, 0x25, 0x99, 0xdb, 0xe2, 0xf7, 0x60,
+	0x48, 0x8c, 0x6f, 0x30, 0xcf, 0xae, 0x50, 0x61, 0xca, 0xbc, 0x46, 0xc6, 0xad, 0x65, 0xe6, 0x73,
+	0x06, 0xdb, 0xc4, 0x10, 0xf8, 0x3d, 0xce, 0x35, 0xa6, 0xac, 0x43, 0xe6, 0x2d, 0x27, 0x49, 0x6d,
+	0xd2, 0xad, 0x05, 0x49, 0x00, 0xdd, 0x83, 0xaf, 0x21, 0x5a, 0x3f, 0x09, 0x84, 0xe6, 0x88, 0xb3,
+	0xba, 0xba, 0x60, 0x5b, 0x24, 0xdd, 0x30, 0xe4, 0x8a, 0x79, 0x9c, 0xaf, 0x9f, 0x6b, 0x41, 0x3f,
+	0xc3, 0x1a, 0x99, 0x7f, 0xf0, 0x87, 0x07, 0x7d, 0xd7, 0x5b, 0x14, 0xdc, 0x54, 0xa9, 0x53, 0x59,
+	0xa2, 0x75, 0x77, 0xaa, 0x94, 0x73, 0x7f, 0x52, 0x90, 0xbb, 0x96, 0x75, 0x4c, 0xb5, 0x32, 0x1f,
+	0x4b, 0xe6, 0x93, 0x4d, 0xa3, 0xa2, 0x9f, 0xc8, 0xba, 0x24, 0x7f, 0x2d, 0xe3, 0xa4, 0xd4, 0xf4,
+	0xe9, 0xca, 0x59, 0xe0, 0x94, 0x8e, 0x6a, 0x7d, 0xf1, 0x24, 0xc9, 0x72, 0xf2, 0x7a, 0xa3, 0x64,
+	0x7b, 0xa9, 0x47, 0x41, 0x4e, 0x95, 0x9a, 0x5e, 0x53, 0x7b, 0xf4, 0x29, 0x09, 0x53, 0xa5, 0x5e,
+	0x5e, 0x28, 0xa9, 0x35, 0x29, 0x84, 0xe7, 0x3d, 0x33, 0x06, 0x9f, 0xfe, 0x15, 0x00, 0x00, 0xff,
+	0xff, 0xe3, 0xf2, 0x43, 0xec, 0x34, 0x0b, 0x00, 0x00,
 }
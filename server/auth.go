@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Authenticator check login username and credential
+type Authenticator interface {
+	Authenticate(username, credential string) error
+}
+
+//PasswordIterations pbkdf2 rounds of a new user, kept in the line so it can be raised later
+const PasswordIterations = 100000
+
+type userEntry struct {
+	//iterations of pbkdf2, 0 is a legacy single sha256 line
+	iterations int
+	salt       string
+	hash       string
+}
+
+//FileAuthenticator users store in a file, one user a line
+//username:pbkdf2-sha256:iterations:salt:hex(pbkdf2(password, salt))
+//legacy username:salt:hex(sha256(salt + password)) is still accepted
+type FileAuthenticator struct {
+	path  string
+	mutex sync.RWMutex
+	users map[string]userEntry
+}
+
+//NewFileAuthenticator load users from path, a missing file is an empty store
+func NewFileAuthenticator(path string) (*FileAuthenticator, error) {
+	a := &FileAuthenticator{
+		path:  path,
+		users: make(map[string]userEntry),
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		user, name, err := parseUser(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d %v", path, line, err)
+		}
+		if user.iterations == 0 {
+			log.Printf("%s:%d user %s has a legacy sha256 hash, add it again\n", path, line, name)
+		}
+		a.users[name] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	log.Printf("load %d users from %s\n", len(a.users), path)
+	return a, nil
+}
+
+func parseUser(text string) (userEntry, string, error) {
+	v := strings.Split(text, ":")
+	switch {
+	case len(v) == 3:
+		return userEntry{salt: v[1], hash: v[2]}, v[0], nil
+	case len(v) == 5 && v[1] == "pbkdf2-sha256":
+		iterations, err := strconv.Atoi(v[2])
+		if err != nil || iterations <= 0 {
+			return userEntry{}, "", fmt.Errorf("bad iterations %q", v[2])
+		}
+		return userEntry{iterations: iterations, salt: v[3], hash: v[4]}, v[0], nil
+	}
+	return userEntry{}, "", fmt.Errorf("bad user line")
+}
+
+func hashPassword(salt, password string, iterations int) string {
+	if iterations == 0 {
+		sum := sha256.Sum256([]byte(salt + password))
+		return hex.EncodeToString(sum[:])
+	}
+	return hex.EncodeToString(pbkdf2SHA256([]byte(password), []byte(salt), iterations))
+}
+
+//pbkdf2SHA256 rfc 8018 with hmac-sha256, one block of 32 bytes
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+//Authenticate implement Authenticator
+func (a *FileAuthenticator) Authenticate(username, credential string) error {
+	a.mutex.RLock()
+	user, ok := a.users[username]
+	a.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("user %s non-exsit", username)
+	}
+	hash := hashPassword(user.salt, credential, user.iterations)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(user.hash)) != 1 {
+		return fmt.Errorf("user %s wrong password", username)
+	}
+	return nil
+}
+
+//AddUser append user to the file with a random salt
+func (a *FileAuthenticator) AddUser(username, password string) error {
+	if username == "" || strings.ContainsAny(username, ":\r\n") {
+		return fmt.Errorf("invalid username %q", username)
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, ok := a.users[username]; ok {
+		return fmt.Errorf("user %s exsit", username)
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	user := userEntry{iterations: PasswordIterations, salt: hex.EncodeToString(b)}
+	user.hash = hashPassword(user.salt, password, user.iterations)
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s:pbkdf2-sha256:%d:%s:%s\n", username, user.iterations, user.salt, user.hash); err != nil {
+		return err
+	}
+	a.users[username] = user
+	return nil
+}
+
+//SetAuthenticator check every login, call before Serve or any Listen
+func (s *Server) SetAuthenticator(a Authenticator) {
+	s.auth = a
+}
+
+//SetLoginTimeout player is stopped if not login in time
+func (s *Server) SetLoginTimeout(timeout time.Duration) {
+	s.loginTimeout = timeout
+}
+
+//waitLogin stop the player if it does not login in time
+func (s *Server) waitLogin(p *Player) {
+	time.AfterFunc(s.loginTimeout, func() {
+		if !p.IsLogin() {
+			p.stop(fmt.Errorf("player(%d) login timeout", p.index))
+		}
+	})
+}
+
+//requireLogin only Login, Ping, Abnormal and Logout are accepted before login
+func (s *Server) requireLogin(next HandleFunc) HandleFunc {
+	return func(p *Player, cmd int32, msg []byte) (proto.Message, error) {
+		if p.IsLogin() {
+			return next(p, cmd, msg)
+		}
+		switch protocol.C2SCmd(cmd) {
+		case protocol.C2SCmd_Login, protocol.C2SCmd_Ping, protocol.C2SCmd_Abnormal, protocol.C2SCmd_Logout:
+			return next(p, cmd, msg)
+		}
+		return nil, protocol.NewError(protocol.ErrCode_ErrNotLogin, "protocol(%d) need login", cmd)
+	}
+}
+
+//SetGuest allow login with a client token instead of username
+func (s *Server) SetGuest(enable bool) {
+	s.guest = enable
+}
+
+//MinTokenSize guest token must not be easy to guess
+const MinTokenSize = 16
+
+//guestID the same token always get the same id, token itself is never shown
+func guestID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "guest-" + hex.EncodeToString(sum[:6])
+}
+
+//authenticate return user id and display name
+//without username, CN of client certificate is used, then guest token
+func (s *Server) authenticate(p *Player, req *protocol.C2SLogin) (string, string, error) {
+	if req.Username == "" {
+		if name := p.certName(); name != "" {
+			return name, name, nil
+		}
+		if !s.guest {
+			return "", "", protocol.NewError(protocol.ErrCode_ErrAuthFailed, "guest is not allowed")
+		}
+		if len(req.Token) < MinTokenSize {
+			return "", "", protocol.NewError(protocol.ErrCode_ErrAuthFailed, "token at least %d bytes", MinTokenSize)
+		}
+		id := guestID(req.Token)
+		return id, id, nil
+	}
+	if s.auth == nil {
+		return "", "", protocol.NewError(protocol.ErrCode_ErrAuthFailed, "no authenticator")
+	}
+	if err := s.auth.Authenticate(req.Username, req.Credential); err != nil {
+		log.Printf("player(%d) login: %v\n", p.index, err)
+		return "", "", protocol.NewError(protocol.ErrCode_ErrAuthFailed, "wrong username or credential")
+	}
+	return req.Username, req.Username, nil
+}
+
+func (s *Server) login(p *Player, msg proto.Message) (proto.Message, error) {
+	if p.IsLogin() {
+		return nil, protocol.NewError(protocol.ErrCode_ErrBadRequest, "already login")
+	}
+	id, name, err := s.authenticate(p, msg.(*protocol.C2SLogin))
+	if err != nil {
+		return nil, err
+	}
+	p.setLogin(id, name)
+	old := s.bindID(p)
+	if old != nil && s.takeover(old) && s.resume(old, p) {
+		s.flushOffline(p)
+		return &protocol.S2CLogin{Id: id, Resumed: true}, nil
+	}
+	if old != nil {
+		old.stop(fmt.Errorf("player(%d) %s login elsewhere", old.index, id))
+	}
+	log.Printf("player(%d) login as %s\n", p.index, id)
+	s.welcome(p)
+	s.flushOffline(p)
+	return &protocol.S2CLogin{Id: id}, nil
+}
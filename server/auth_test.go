@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	//rfc 7914 section 11 style vectors of pbkdf2-hmac-sha256
+	tests := []struct {
+		iterations int
+		want       string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), tt.iterations))
+		if got != tt.want {
+			t.Errorf("%d iterations: got %s, want %s", tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestFileAuthenticator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.txt")
+	//legacy line of sha256("salt" + "old")
+	legacy := "carol:salt:" + hashPassword("salt", "old", 0) + "\n"
+	if err := ioutil.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewFileAuthenticator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddUser("bob", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), "bob:pbkdf2-sha256:100000:") {
+		t.Fatalf("parameters are not stored: %s", data)
+	}
+	a, err = NewFileAuthenticator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		user, password string
+		ok             bool
+	}{
+		{"bob", "pw", true},
+		{"bob", "wrong", false},
+		{"carol", "old", true},
+		{"carol", "wrong", false},
+		{"dave", "pw", false},
+	}
+	for _, tt := range tests {
+		if err := a.Authenticate(tt.user, tt.password); (err == nil) != tt.ok {
+			t.Errorf("%s/%s: got %v", tt.user, tt.password, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//MaxUnread chats a player may send read receipt for, the oldest is forgotten first
+const MaxUnread = 1024
+
+//receiptKey a chat delivered to a player
+type receiptKey struct {
+	from  string
+	msgID uint64
+}
+
+//unread chats delivered to a player and not read yet, a read receipt must match one
+type unread struct {
+	mutex sync.Mutex
+	keys  map[receiptKey]struct{}
+	order []receiptKey
+}
+
+func (u *unread) add(key receiptKey) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if u.keys == nil {
+		u.keys = make(map[receiptKey]struct{})
+	}
+	if _, ok := u.keys[key]; ok {
+		return
+	}
+	u.keys[key] = struct{}{}
+	u.order = append(u.order, key)
+	if len(u.order) > MaxUnread {
+		delete(u.keys, u.order[0])
+		u.order = u.order[1:]
+	}
+}
+
+//take return false if key is not delivered or already read
+func (u *unread) take(key receiptKey) bool {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if _, ok := u.keys[key]; !ok {
+		return false
+	}
+	delete(u.keys, key)
+	return true
+}
+
+//moveTo give every unread chat to another player of the same user
+func (u *unread) moveTo(other *unread) {
+	u.mutex.Lock()
+	order := u.order
+	u.keys, u.order = nil, nil
+	u.mutex.Unlock()
+	for _, key := range order {
+		other.add(key)
+	}
+}
+
+//Chat send msg to target user, return delivery status for the sender
+func (s *Server) Chat(p *Player, chat *protocol.C2SChat) *protocol.S2CChatStatus {
+	status := &protocol.S2CChatStatus{
+		MsgId:  chat.MsgId,
+		Target: chat.User,
+		State:  protocol.ChatState_ChatDelivered,
+	}
+	target, ok := s.GetPlayerByID(chat.User)
+	if !ok {
+		s.storeChat(p, chat, status)
+		return status
+	}
+	//a detached player may never come back, its kept pushes are dropped then
+	if target.IsDetached() && s.store != nil {
+		s.pushOffline(p, chat, status)
+		return status
+	}
+	if err := target.Send(protocol.S2CCmd_ChatMsg, &protocol.S2CChat{
+		Context: chat.Context,
+		MsgId:   chat.MsgId,
+		From:    p.GetID(),
+		Time:    time.Now().UnixNano(),
+	}); err != nil {
+		status.State = protocol.ChatState_ChatRejected
+		status.Reason = err.Error()
+		return status
+	}
+	if chat.MsgId != 0 {
+		target.unread.add(receiptKey{p.GetID(), chat.MsgId})
+	}
+	if target.IsDetached() {
+		//moved to the box if not resumed in time
+		status.State = protocol.ChatState_ChatStored
+		if s.store == nil {
+			status.State = protocol.ChatState_ChatOffline
+			status.Reason = "kept until " + chat.User + " resume"
+		}
+	}
+	return status
+}
+
+//ReadReceipt tell the sender its msg has been read by p
+//a receipt for a msg never delivered to p is dropped
+func (s *Server) ReadReceipt(p *Player, receipt *protocol.C2SReadReceipt) {
+	if !p.unread.take(receiptKey{receipt.From, receipt.MsgId}) {
+		log.Printf("player(%d) read receipt of %s message(%d) not delivered\n", p.index, receipt.From, receipt.MsgId)
+		return
+	}
+	sender, ok := s.GetPlayerByID(receipt.From)
+	if !ok {
+		return
+	}
+	if err := sender.Send(protocol.S2CCmd_ChatStatus, &protocol.S2CChatStatus{
+		MsgId:  receipt.MsgId,
+		Target: p.GetID(),
+		State:  protocol.ChatState_ChatRead,
+	}); err != nil {
+		log.Printf("player(%d) read receipt: %v\n", sender.index, err)
+	}
+}
+
+func (s *Server) registerChatHandles() {
+	s.RegisterMessage(protocol.C2SCmd_Chat, &protocol.C2SChat{}, func(p *Player, msg proto.Message) {
+		status := s.Chat(p, msg.(*protocol.C2SChat))
+		if status.State != protocol.ChatState_ChatDelivered {
+			log.Printf("player(%d) chat to %s: %s %s\n", p.index, status.Target, status.State, status.Reason)
+		}
+		if err := p.Send(protocol.S2CCmd_ChatStatus, status); err != nil {
+			log.Printf("player(%d) chat status: %v\n", p.index, err)
+		}
+	})
+	s.RegisterMessage(protocol.C2SCmd_ReadReceipt, &protocol.C2SReadReceipt{}, func(p *Player, msg proto.Message) {
+		s.ReadReceipt(p, msg.(*protocol.C2SReadReceipt))
+	})
+}
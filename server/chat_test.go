@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestUnread(t *testing.T) {
+	var u unread
+	u.add(receiptKey{"bob", 1})
+	if u.take(receiptKey{"bob", 2}) || u.take(receiptKey{"eve", 1}) {
+		t.Fatal("receipt of a chat never delivered is taken")
+	}
+	if !u.take(receiptKey{"bob", 1}) {
+		t.Fatal("receipt of a delivered chat is dropped")
+	}
+	if u.take(receiptKey{"bob", 1}) {
+		t.Fatal("receipt is taken twice")
+	}
+	for i := 0; i <= MaxUnread; i++ {
+		u.add(receiptKey{"bob", uint64(i + 10)})
+	}
+	if u.take(receiptKey{"bob", 10}) {
+		t.Fatal("oldest chat is not forgotten")
+	}
+	var other unread
+	u.moveTo(&other)
+	if u.take(receiptKey{"bob", 11}) || !other.take(receiptKey{"bob", 11}) {
+		t.Fatal("unread chats are not moved")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "sync"
+
+//indexPool allocate connection index, a released index is reused first
+//alloc and release are O(1)
+type indexPool struct {
+	mutex sync.Mutex
+	next  uint64
+	free  []uint64
+}
+
+//alloc return an unused index, never 0
+func (p *indexPool) alloc() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if n := len(p.free); n > 0 {
+		index := p.free[n-1]
+		p.free = p.free[:n-1]
+		return index
+	}
+	p.next++
+	return p.next
+}
+
+//release index for reuse, must be allocated and released only once
+func (p *indexPool) release(index uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.free = append(p.free, index)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestIndexPool(t *testing.T) {
+	var pool indexPool
+	var mutex sync.Mutex
+	used := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				index := pool.alloc()
+				mutex.Lock()
+				if index == 0 || used[index] {
+					t.Errorf("index %d allocated twice", index)
+				}
+				used[index] = true
+				mutex.Unlock()
+				if i%2 == 0 {
+					mutex.Lock()
+					delete(used, index)
+					mutex.Unlock()
+					pool.release(index)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func newBenchServer(players int) *Server {
+	s := &Server{
+		players: newPlayerMap(),
+		ids:     newIDMap(),
+		rooms:   make(map[string]map[uint64]*Player),
+	}
+	for i := 0; i < players; i++ {
+		s.addPlayer(&Player{s: s})
+	}
+	return s
+}
+
+//linearFreeIndex the scan replaced by indexPool, kept to compare
+func linearFreeIndex(s *Server, last *uint64) uint64 {
+	for i := uint64(1); i <= *last; i++ {
+		if _, ok := s.GetPlayer(i); !ok {
+			return i
+		}
+	}
+	*last++
+	return *last
+}
+
+//BenchmarkAlloc one accept and one leave with n players connected
+func BenchmarkAlloc(b *testing.B) {
+	for _, n := range []int{10000, 50000, 100000} {
+		b.Run(fmt.Sprintf("pool/%d", n), func(b *testing.B) {
+			s := newBenchServer(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p := &Player{s: s}
+				s.addPlayer(p)
+				s.DelPlayer(p.index)
+			}
+		})
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			s := newBenchServer(n)
+			last := uint64(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p := &Player{s: s, index: linearFreeIndex(s, &last)}
+				s.players.Store(p)
+				s.players.Delete(p.index)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Rate token bucket, PerSecond tokens are added every second up to Burst
+//zero PerSecond is no limit
+type Rate struct {
+	PerSecond float64
+	Burst     float64
+}
+
+func (r Rate) String() string {
+	if r.PerSecond <= 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(r.PerSecond, 'g', -1, 64) + ":" + strconv.FormatFloat(r.Burst, 'g', -1, 64)
+}
+
+//Set implement flag.Value, rate:burst, burst is rate if omitted, 0 no limit
+func (r *Rate) Set(value string) error {
+	v := strings.SplitN(value, ":", 2)
+	perSecond, err := strconv.ParseFloat(v[0], 64)
+	if err != nil || perSecond < 0 {
+		return fmt.Errorf("bad rate %q, please input: rate:burst", value)
+	}
+	burst := perSecond
+	if len(v) == 2 {
+		if burst, err = strconv.ParseFloat(v[1], 64); err != nil || burst < 0 {
+			return fmt.Errorf("bad burst %q, please input: rate:burst", value)
+		}
+	}
+	r.PerSecond, r.Burst = perSecond, burst
+	return nil
+}
+
+//CmdRates rate of each protocol
+type CmdRates map[int32]Rate
+
+func (c CmdRates) String() string {
+	var list []string
+	for cmd, r := range c {
+		list = append(list, protocol.C2SCmd(cmd).String()+"="+r.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+//Set implement flag.Value, Chat=5:10,RoomChat=5:10
+func (c CmdRates) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		v := strings.SplitN(item, "=", 2)
+		cmd, ok := protocol.C2SCmd_value[v[0]]
+		if len(v) != 2 || !ok {
+			return fmt.Errorf("bad command rate %q, please input: Chat=rate:burst", item)
+		}
+		var r Rate
+		if err := r.Set(v[1]); err != nil {
+			return err
+		}
+		c[cmd] = r
+	}
+	return nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//refill add tokens since last refill
+func (b *bucket) refill(r Rate, now time.Time) {
+	if r.PerSecond <= 0 {
+		return
+	}
+	burst := r.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * r.PerSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+}
+
+//wait return 0 if a token can be taken, or how long to wait for one, call after refill
+func (b *bucket) wait(r Rate) time.Duration {
+	if r.PerSecond <= 0 || b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / r.PerSecond * float64(time.Second))
+}
+
+//consume a token, call only after wait return 0
+func (b *bucket) consume(r Rate) {
+	if r.PerSecond > 0 {
+		b.tokens--
+	}
+}
+
+//take return 0 if a token is taken, or how long to wait for one
+func (b *bucket) take(r Rate, now time.Time) time.Duration {
+	b.refill(r, now)
+	wait := b.wait(r)
+	if wait == 0 {
+		b.consume(r)
+	}
+	return wait
+}
+
+//RateLimit inbound command limits, zero value is no limit
+type RateLimit struct {
+	//Player every command of a player
+	Player Rate
+	//Command each command of a player
+	Command CmdRates
+	//IP every command from the same remote ip
+	IP Rate
+	//Offense throttled commands a player may send, disconnected when used up
+	Offense Rate
+	//MaxConnsPerIP connections from the same remote ip, 0 no limit
+	MaxConnsPerIP int
+}
+
+//playerLimit buckets of a player
+type playerLimit struct {
+	mutex   sync.Mutex
+	total   bucket
+	cmds    map[int32]*bucket
+	offense bucket
+}
+
+//ipLimit state of a remote ip
+type ipLimit struct {
+	conns  int
+	bucket bucket
+}
+
+//SetRateLimit call before Serve or any Listen
+func (s *Server) SetRateLimit(limit RateLimit) {
+	s.limit = limit
+}
+
+//remoteIP host of remote address, empty for local transport such as unix socket and pipe
+func remoteIP(addr net.Addr) string {
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return ""
+}
+
+//acquireIP return false if ip has too many connections, empty ip is not limited
+func (s *Server) acquireIP(ip string) bool {
+	if ip == "" {
+		return true
+	}
+	s.ipMutex.Lock()
+	defer s.ipMutex.Unlock()
+	state, ok := s.ips[ip]
+	if !ok {
+		state = &ipLimit{}
+		s.ips[ip] = state
+	}
+	if s.limit.MaxConnsPerIP > 0 && state.conns >= s.limit.MaxConnsPerIP {
+		return false
+	}
+	state.conns++
+	return true
+}
+
+func (s *Server) releaseIP(ip string) {
+	s.ipMutex.Lock()
+	defer s.ipMutex.Unlock()
+	state, ok := s.ips[ip]
+	if !ok {
+		return
+	}
+	state.conns--
+	if state.conns <= 0 {
+		delete(s.ips, ip)
+	}
+}
+
+//takeIP token from the bucket of ip
+func (s *Server) takeIP(ip string, now time.Time) time.Duration {
+	if s.limit.IP.PerSecond <= 0 {
+		return 0
+	}
+	s.ipMutex.Lock()
+	defer s.ipMutex.Unlock()
+	state, ok := s.ips[ip]
+	if !ok {
+		return 0
+	}
+	return state.bucket.take(s.limit.IP, now)
+}
+
+//take token from buckets of p and from takeIP, tokens are only taken if every bucket has one
+//any throttle is charged as an offense, return true if p should be disconnected
+func (l *playerLimit) take(limit *RateLimit, cmd int32, now time.Time, takeIP func() time.Duration) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.total.refill(limit.Player, now)
+	wait := l.total.wait(limit.Player)
+	r, ok := limit.Command[cmd]
+	var b *bucket
+	if ok {
+		if l.cmds == nil {
+			l.cmds = make(map[int32]*bucket)
+		}
+		if b, ok = l.cmds[cmd]; !ok {
+			b = &bucket{}
+			l.cmds[cmd] = b
+		}
+		b.refill(r, now)
+		if w := b.wait(r); w > wait {
+			wait = w
+		}
+	}
+	if wait == 0 {
+		wait = takeIP()
+	}
+	if wait == 0 {
+		l.total.consume(limit.Player)
+		if b != nil {
+			b.consume(r)
+		}
+		return 0, false
+	}
+	return wait, limit.Offense.PerSecond > 0 && l.offense.take(limit.Offense, now) > 0
+}
+
+//rateLimit throttle commands over limit, stop players keep sending throttled commands
+func (s *Server) rateLimit(next HandleFunc) HandleFunc {
+	return func(p *Player, cmd int32, msg []byte) (proto.Message, error) {
+		now := time.Now()
+		wait, offender := p.limit.take(&s.limit, cmd, now, func() time.Duration {
+			return s.takeIP(p.ip, now)
+		})
+		if wait == 0 {
+			return next(p, cmd, msg)
+		}
+		if offender {
+			p.Quit(fmt.Errorf("player(%d) %s throttled too often", p.index, p.ip))
+		}
+		return nil, &protocol.S2CError{
+			Code:       protocol.ErrCode_ErrThrottled,
+			Message:    fmt.Sprintf("protocol(%d) too frequent", cmd),
+			RetryAfter: int64(wait),
+		}
+	}
+}
+
+//replyThrottled tell player a message without request is throttled
+func (p *Player) replyThrottled(cmd int32, err error) bool {
+	e, ok := err.(*protocol.S2CError)
+	if !ok || e.Code != protocol.ErrCode_ErrThrottled {
+		return false
+	}
+	if err := p.Send(protocol.S2CCmd_Error, e); err != nil && err != ErrPlayerStopped {
+		log.Printf("player(%d) throttled protocol(%d): %v\n", p.index, cmd, err)
+	}
+	return true
+}
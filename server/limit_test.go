@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	r := Rate{PerSecond: 2, Burst: 2}
+	var b bucket
+	now := time.Now()
+	if b.take(r, now) != 0 || b.take(r, now) != 0 {
+		t.Fatal("burst is not allowed")
+	}
+	if wait := b.take(r, now); wait != 500*time.Millisecond {
+		t.Fatalf("wait %s, want 500ms", wait)
+	}
+	if b.take(r, now.Add(500*time.Millisecond)) != 0 {
+		t.Fatal("token is not refilled")
+	}
+	if b.take(Rate{}, now) != 0 {
+		t.Fatal("zero rate is limited")
+	}
+}
+
+func TestPlayerLimitIPThrottle(t *testing.T) {
+	limit := &RateLimit{
+		Player:  Rate{PerSecond: 1, Burst: 1},
+		Offense: Rate{PerSecond: 1, Burst: 2},
+	}
+	var l playerLimit
+	now := time.Now()
+	ipThrottled := func() time.Duration { return time.Second }
+	//offense burst is 2, the third throttle by ip disconnect
+	for i := 0; i < 3; i++ {
+		wait, offender := l.take(limit, 1, now, ipThrottled)
+		if wait == 0 || offender != (i == 2) {
+			t.Fatalf("throttle %d: got %s, %v", i, wait, offender)
+		}
+	}
+	//player token is not spent by commands the ip throttled
+	if wait, _ := l.take(limit, 1, now, func() time.Duration { return 0 }); wait != 0 {
+		t.Fatal("player token is spent by a throttled command")
+	}
+}
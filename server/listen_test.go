@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chat.sock")
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := removeStaleSocket(path); err == nil {
+		t.Fatal("socket of a live server is removed")
+	}
+	//socket file is left like a crashed server
+	l.SetUnlinkOnClose(false)
+	l.Close()
+	if err := removeStaleSocket(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("stale socket is kept")
+	}
+}
+
+func TestServePipeWithoutTLS(t *testing.T) {
+	s := NewServer()
+	s.SetTLS(&tls.Config{})
+	l := NewPipeListener()
+	defer l.Close()
+	go s.Serve(l)
+	conn, err := l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := <-s.chConn
+	defer c.Close()
+	if _, ok := c.(*streamTransport).Conn.(*tls.Conn); ok {
+		t.Fatal("pipe listener is served with tls")
+	}
+}
+
+func TestListenFailNotBlock(t *testing.T) {
+	s := NewServer()
+	done := make(chan struct{})
+	go func() {
+		//both fail, Run only read the first
+		s.ListenTCP("bad address")
+		s.ListenUnix("/nonexistent/dir/chat.sock")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("second listen failure block")
+	}
+	if err := <-s.chStop; err == nil {
+		t.Fatal("listen failure is not reported")
+	}
+}
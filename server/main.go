@@ -1,15 +1,18 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/xlplbo/go_protobuf_test/protocol"
@@ -17,131 +20,237 @@ import (
 
 //Player struct
 type Player struct {
-	index  uint64
-	conn   net.Conn
-	s      *Server
-	chStop chan error
+	index     uint64
+	conn      Transport
+	s         *Server
+	chStop    chan error
+	chDone    chan struct{}
+	chSend    chan []byte
+	chClosing chan struct{}
+	ip        string
+	limit     playerLimit
+	unread    unread
+
+	mutex    sync.RWMutex
+	id       string
+	name     string
+	login    bool
+	detached bool
+	//quit stop without keeping the session for resume
+	quit   bool
+	missed [][]byte
+	expire *time.Timer
 }
 
 //Play Run
 func (p *Player) Play() {
+	go p.write()
 	go func() {
-		var data []byte
-		buff := make([]byte, protocol.MaxSize)
 		for {
-			n, err := p.conn.Read(buff)
-			if err != nil {
-				log.Println(err)
+			if timeout := p.s.heartbeat * time.Duration(p.s.heartbeatMisses); timeout > 0 {
+				p.conn.SetReadDeadline(time.Now().Add(timeout))
+			}
+			pkg, err := p.conn.ReadPackage()
+			if e, ok := err.(net.Error); ok && e.Timeout() {
+				log.Printf("player(%d) miss %d heartbeats\n", p.index, p.s.heartbeatMisses)
 				p.Stop()
 				return
 			}
-			data = append(data, buff[:n]...)
-			for {
-				offset, serial, buff := protocol.UnPack(data)
-				if buff == nil {
-					break
-				}
-				data = data[offset:]
-				if f, ok := p.s.handles[serial]; ok {
-					f(p, buff)
-					continue
+			if err == protocol.ErrFrameTooLarge {
+				log.Printf("player(%d) frame exceed %d bytes\n", p.index, p.s.maxFrameSize)
+				p.Stop()
+				return
+			}
+			if err != nil {
+				select {
+				case <-p.chDone:
+					return
+				default:
 				}
-				log.Printf("protocol id(%d) not handle\n", serial)
+				log.Printf("player(%d) read: %v\n", p.index, err)
+				p.Stop()
+				return
 			}
+			p.s.dispatch(p, pkg)
 		}
 	}()
 	err := <-p.chStop
+	detached := p.detach()
+	close(p.chDone)
 	p.conn.Close()
-	p.s.DelPlayer(p.index)
+	p.s.releaseIP(p.ip)
+	if detached {
+		p.waitResume()
+	} else {
+		p.s.leave(p)
+	}
+	p.s.playing.Done()
 	log.Println(err)
 }
 
 //Stop player
 func (p *Player) Stop() {
-	p.chStop <- fmt.Errorf("player(%d) stop", p.index)
+	p.stop(fmt.Errorf("player(%d) stop", p.index))
 }
 
-//GetTargetPlayer ...
-func (p *Player) GetTargetPlayer(index uint64) *Player {
-	player, ok := p.s.GetPlayer(index)
-	if !ok {
-		log.Printf("player(%d) non-exsit", index)
-		return nil
+//Quit stop player for good, the session is not kept for resume
+func (p *Player) Quit(err error) {
+	p.mutex.Lock()
+	p.quit = true
+	p.mutex.Unlock()
+	p.stop(err)
+}
+
+func (p *Player) stop(err error) {
+	select {
+	case p.chStop <- err:
+	case <-p.chDone:
 	}
-	return player
 }
 
-//SendChat ...
-func (p *Player) SendChat(msg string) {
-	if err := protocol.Send2Client(p.conn, protocol.S2CCmd_Result, &protocol.S2CResult{
-		Context: msg,
-	}); err != nil {
-		log.Println(err)
+//Reply answer request, err can be *protocol.S2CError
+func (p *Player) Reply(request uint32, msg proto.Message, err error) {
+	serial := protocol.S2CCmd_Reply
+	if err != nil {
+		e, ok := err.(*protocol.S2CError)
+		if !ok {
+			e = protocol.NewError(protocol.ErrCode_ErrInternal, "%v", err)
+		}
+		serial, msg = protocol.S2CCmd_Error, e
+	}
+	err = p.send(serial, request, msg)
+	if err == protocol.ErrFrameTooLarge {
+		//the client would drop the connection for it, fail the request instead
+		log.Printf("player(%d) reply protocol(%d): %v\n", p.index, serial, err)
+		err = p.send(protocol.S2CCmd_Error, request, protocol.NewError(protocol.ErrCode_ErrInternal, "reply exceed %d bytes", p.s.maxFrameSize))
+	}
+	if err != nil {
+		log.Printf("player(%d) reply: %v\n", p.index, err)
 	}
 }
 
-//GetIndex ...
+//IsLogin player has passed Authenticator
+func (p *Player) IsLogin() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.login
+}
+
+//GetID stable user id, it does not change after reconnect
+func (p *Player) GetID() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.id
+}
+
+//GetName display name
+func (p *Player) GetName() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.name
+}
+
+func (p *Player) setName(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.name = name
+}
+
+func (p *Player) setLogin(id, name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.id = id
+	p.name = name
+	p.login = true
+}
+
+//GetIndex connection index, only used inside server
 func (p *Player) GetIndex() uint64 {
 	return p.index
 }
 
 //Server center
 type Server struct {
-	index   uint64
-	players map[uint64]*Player
-	mutex   *sync.RWMutex
-	handles map[int32]func(*Player, []byte)
-	chStop  chan error
-	chConn  chan net.Conn
-	chSig   chan os.Signal
-}
-
-func (s *Server) getFreeIndex() uint64 {
-	var i uint64 = 1
-	for i = 1; i <= s.index; i++ {
-		if _, ok := s.GetPlayer(i); !ok {
-			return i
-		}
-	}
-	s.index++
-	return s.index
+	name         string
+	indexes      indexPool
+	players      *playerMap
+	ids          *idMap
+	handles      map[int32]func(*Player, []byte)
+	requests     map[int32]func(*Player, []byte) (proto.Message, error)
+	middlewares  []Middleware
+	chain        HandleFunc
+	chStop       chan error
+	chConn       chan Transport
+	chSig        chan os.Signal
+	maxFrameSize int
+
+	sendQueueSize int
+	sendPolicy    SendPolicy
+	sendTimeout   time.Duration
+
+	heartbeat       time.Duration
+	heartbeatMisses int
+
+	closeMutex      sync.RWMutex
+	closing         bool
+	listeners       map[net.Listener]struct{}
+	handling        sync.WaitGroup
+	playing         sync.WaitGroup
+	shutdownTimeout time.Duration
+
+	auth         Authenticator
+	loginTimeout time.Duration
+	guest        bool
+
+	rooms     map[string]map[uint64]*Player
+	roomMutex sync.RWMutex
+
+	store MessageStore
+
+	limit   RateLimit
+	ips     map[string]*ipLimit
+	ipMutex sync.Mutex
+
+	tlsConfig *tls.Config
+	wsOrigins map[string]bool
+
+	resumeGrace  time.Duration
+	resumeBuffer int
+}
+
+//GetPlayerByID login player of user id
+func (s *Server) GetPlayerByID(id string) (*Player, bool) {
+	return s.ids.Load(id)
+}
+
+//bindID route id to p, return the player login with the same id before
+func (s *Server) bindID(p *Player) *Player {
+	return s.ids.Swap(p.GetID(), p)
+}
+
+//unbindID return false if id has been bind to another player
+func (s *Server) unbindID(p *Player) bool {
+	return s.ids.CompareAndDelete(p.GetID(), p)
 }
 
 func (s *Server) getPlayerList() []*Player {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
 	var list []*Player
-	for _, p := range s.players {
+	s.players.Range(func(p *Player) bool {
 		list = append(list, p)
-	}
+		return true
+	})
 	return list
 }
 
 //GetPlayer ...
 func (s *Server) GetPlayer(index uint64) (*Player, bool) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-	player, ok := s.players[index]
-	return player, ok
-}
-
-func (s *Server) setPlayer(index uint64, p *Player) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	s.players[index] = p
+	return s.players.Load(index)
 }
 
-func (s *Server) brocastPlayerList() {
-	var buf bytes.Buffer
-	buf.WriteString("playerlist:")
-	var array []string
-	for _, p := range s.getPlayerList() {
-		array = append(array, strconv.FormatUint(p.GetIndex(), 10))
-	}
-	buf.WriteString(strings.Join(array, ","))
-	for _, p := range s.getPlayerList() {
-		p.SendChat(buf.String() + fmt.Sprintf(" your id: %d", p.GetIndex()))
-	}
+//addPlayer give p a free index and add it
+func (s *Server) addPlayer(p *Player) {
+	p.index = s.indexes.alloc()
+	s.players.Store(p)
 }
 
 //Run start service
@@ -149,50 +258,142 @@ func (s *Server) Run() {
 	go func() {
 		for {
 			conn := <-s.chConn
-			index := s.getFreeIndex()
+			ip := remoteIP(conn.RemoteAddr())
+			if !s.acquireIP(ip) {
+				log.Printf("%s too many connections\n", ip)
+				conn.Close()
+				continue
+			}
+			if !s.addPlaying() {
+				s.releaseIP(ip)
+				conn.Close()
+				continue
+			}
 			player := &Player{
-				index:  index,
-				conn:   conn,
-				s:      s,
-				chStop: make(chan error),
+				conn:      conn,
+				ip:        ip,
+				s:         s,
+				chStop:    make(chan error),
+				chDone:    make(chan struct{}),
+				chSend:    make(chan []byte, s.sendQueueSize),
+				chClosing: make(chan struct{}),
 			}
-			s.setPlayer(index, player)
+			s.addPlayer(player)
+			s.waitLogin(player)
 			go player.Play()
-			s.brocastPlayerList()
-			log.Printf("player(%d) %s connect.\n", index, conn.RemoteAddr().String())
+			log.Printf("player(%d) %s connect.\n", player.index, conn.RemoteAddr().String())
 		}
 	}()
 
 	msg := <-s.chStop
-	for _, p := range s.getPlayerList() {
-		p.Stop()
-	}
 	log.Printf("server stop: %s\n", msg.Error())
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Printf("server shutdown: %v\n", err)
+	}
 }
 
 //ListenTCP only call func use go routine
+//served with tls if SetTLS
 func (s *Server) ListenTCP(laddr string) {
 	l, err := net.Listen("tcp", laddr)
 	if err != nil {
-		s.chStop <- err
+		s.stop(err)
+		return
+	}
+	if s.tlsConfig != nil {
+		l = tls.NewListener(l, s.tlsConfig)
+	}
+	if err := s.Serve(l); err != nil {
+		s.stop(err)
+	}
+}
+
+//isConnRefused nobody listen at the address
+func isConnRefused(err error) bool {
+	if e, ok := err.(*net.OpError); ok {
+		err = e.Err
+	}
+	if e, ok := err.(*os.SyscallError); ok {
+		err = e.Err
+	}
+	return err == syscall.ECONNREFUSED
+}
+
+//removeStaleSocket remove socket file at path left by a server not running any more
+//return error if a server still accept on it
+func removeStaleSocket(path string) error {
+	if fi, err := os.Stat(path); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	if !isConnRefused(err) {
+		return err
+	}
+	return os.Remove(path)
+}
+
+//ListenUnix listen unix domain socket at path, only call func use go routine
+//a socket file left by last run is removed, local socket is never served with tls
+func (s *Server) ListenUnix(path string) {
+	if err := removeStaleSocket(path); err != nil {
+		s.stop(err)
+		return
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		s.stop(err)
+		return
+	}
+	if err := s.Serve(l); err != nil {
+		s.stop(err)
 	}
-	defer l.Close()
-	log.Printf("listen at %s\n", l.Addr().String())
+}
+
+//Serve accept players from l until shutdown, can be called with several listeners at once
+//l is served as it is, wrap it with tls.NewListener for tls, l is closed when return
+func (s *Server) Serve(l net.Listener) error {
+	if !s.addListener(l) {
+		l.Close()
+		return nil
+	}
+	defer s.delListener(l)
+	log.Printf("listen at %s %s\n", l.Addr().Network(), l.Addr().String())
 	for {
 		conn, err := l.Accept()
 		if err != nil {
-			log.Println(err)
-			continue
+			if s.isClosing() {
+				return nil
+			}
+			if e, ok := err.(net.Error); ok && e.Temporary() {
+				log.Println(err)
+				continue
+			}
+			return err
 		}
-		s.chConn <- conn
+		s.chConn <- newStreamTransport(conn, s.maxFrameSize)
+	}
+}
+
+//leave p is gone, tell others if it is the login player of its id
+func (s *Server) leave(p *Player) {
+	s.DelPlayer(p.index)
+	if p.IsLogin() && s.unbindID(p) {
+		s.brocastPresence(p, false)
 	}
 }
 
 //DelPlayer ...
 func (s *Server) DelPlayer(key uint64) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	delete(s.players, key)
+	s.leaveAllRooms(key)
+	if s.players.Delete(key) {
+		s.indexes.release(key)
+	}
 }
 
 //RegisterHandle ...
@@ -206,43 +407,262 @@ func (s *Server) RegisterHandle(id protocol.C2SCmd, f func(*Player, []byte)) {
 	log.Printf("register handle protocol(%d)\n", nID)
 }
 
+//RegisterRequest handle return reply message or error
+func (s *Server) RegisterRequest(id protocol.C2SCmd, f func(*Player, []byte) (proto.Message, error)) {
+	nID := int32(id)
+	if _, ok := s.requests[nID]; ok {
+		log.Printf("protocol(%d) request handle repeat\n", nID)
+		return
+	}
+	s.requests[nID] = f
+	log.Printf("register request handle protocol(%d)\n", nID)
+}
+
+//Use append middleware, the first one is the outermost
+//call before Serve or any Listen
+func (s *Server) Use(mw ...Middleware) {
+	s.middlewares = append(s.middlewares, mw...)
+	s.chain = s.route
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		s.chain = s.middlewares[i](s.chain)
+	}
+}
+
+func (s *Server) route(p *Player, cmd int32, msg []byte) (proto.Message, error) {
+	if f, ok := s.requests[cmd]; ok {
+		reply, err := f(p, msg)
+		if err == nil && reply == nil {
+			err = protocol.NewError(protocol.ErrCode_ErrInternal, "protocol(%d) no reply", cmd)
+		}
+		return reply, err
+	}
+	if f, ok := s.handles[cmd]; ok {
+		f(p, msg)
+		return nil, nil
+	}
+	return nil, protocol.NewError(protocol.ErrCode_ErrUnknownCmd, "protocol(%d) not handle", cmd)
+}
+
+//RegisterMessage handle receive msg unmarshal as the type of prototype
+//player is stopped if msg can not be unmarshal
+func (s *Server) RegisterMessage(id protocol.C2SCmd, prototype proto.Message, f func(*Player, proto.Message)) {
+	s.RegisterHandle(id, func(p *Player, buff []byte) {
+		msg, err := protocol.Unmarshal(buff, prototype)
+		if err != nil {
+			log.Printf("player(%d) protocol(%d): %v\n", p.index, id, err)
+			p.Stop()
+			return
+		}
+		f(p, msg)
+	})
+}
+
+//RegisterMessageRequest same as RegisterMessage but handle return reply message or error
+func (s *Server) RegisterMessageRequest(id protocol.C2SCmd, prototype proto.Message, f func(*Player, proto.Message) (proto.Message, error)) {
+	s.RegisterRequest(id, func(p *Player, buff []byte) (proto.Message, error) {
+		msg, err := protocol.Unmarshal(buff, prototype)
+		if err != nil {
+			log.Printf("player(%d) protocol(%d): %v\n", p.index, id, err)
+			p.Stop()
+			return nil, protocol.NewError(protocol.ErrCode_ErrBadRequest, "%v", err)
+		}
+		return f(p, msg)
+	})
+}
+
+func (s *Server) dispatch(p *Player, pkg *protocol.Package) {
+	if !s.addHandling() {
+		return
+	}
+	defer s.handling.Done()
+	reply, err := s.chain(p, pkg.Serial, pkg.Buff)
+	if pkg.Request == 0 {
+		if err != nil && !p.replyThrottled(pkg.Serial, err) {
+			log.Printf("player(%d) protocol(%d): %v\n", p.index, pkg.Serial, err)
+		}
+		return
+	}
+	if err != nil || reply != nil {
+		p.Reply(pkg.Request, reply, err)
+	}
+}
+
+//SetMaxFrameSize limit package body size read from and sent to every player
+//call before Serve or any Listen
+func (s *Server) SetMaxFrameSize(size int) {
+	s.maxFrameSize = size
+}
+
+//SetHeartbeat player is stopped if nothing read in interval*misses
+//interval 0 disable, call before Serve or any Listen
+func (s *Server) SetHeartbeat(interval time.Duration, misses int) {
+	s.heartbeat = interval
+	s.heartbeatMisses = misses
+}
+
 //HandleSignal ...
 func (s *Server) HandleSignal() {
 	signal.Notify(s.chSig, os.Interrupt)
 	sig := <-s.chSig
-	s.chStop <- fmt.Errorf(sig.String())
+	s.stop(fmt.Errorf("%s", sig.String()))
+}
+
+//stop ask Run to shutdown, only the first reason is kept
+//never block, listeners may fail after Run stop reading
+func (s *Server) stop(err error) {
+	select {
+	case s.chStop <- err:
+	default:
+	}
 }
 
 //NewServer instance
 func NewServer() *Server {
 	s := &Server{
-		index:   0,
-		players: make(map[uint64]*Player),
-		handles: make(map[int32]func(*Player, []byte)),
-		chStop:  make(chan error),
-		chConn:  make(chan net.Conn),
-		chSig:   make(chan os.Signal),
-		mutex:   &sync.RWMutex{},
+		name:         "go_protobuf_test",
+		players:      newPlayerMap(),
+		ids:          newIDMap(),
+		handles:      make(map[int32]func(*Player, []byte)),
+		requests:     make(map[int32]func(*Player, []byte) (proto.Message, error)),
+		chStop:       make(chan error, 1),
+		chConn:       make(chan Transport),
+		chSig:        make(chan os.Signal),
+		maxFrameSize: protocol.MaxFrameSize,
+
+		sendQueueSize: 64,
+		sendPolicy:    SendDrop,
+		sendTimeout:   time.Second,
+
+		heartbeat:       10 * time.Second,
+		heartbeatMisses: 3,
+
+		listeners:       make(map[net.Listener]struct{}),
+		shutdownTimeout: 5 * time.Second,
+
+		loginTimeout: 10 * time.Second,
+
+		rooms: make(map[string]map[uint64]*Player),
+
+		ips: make(map[string]*ipLimit),
 	}
+	s.Use(Recover, s.rateLimit, s.requireLogin)
 	s.RegisterHandle(protocol.C2SCmd_Abnormal, func(p *Player, msg []byte) {
-		p.Stop()
+		p.Quit(fmt.Errorf("player(%d) abnormal", p.index))
 	})
-	s.RegisterHandle(protocol.C2SCmd_Chat, func(p *Player, msg []byte) {
-		var chatMsg protocol.C2SChat
-		if err := proto.Unmarshal(msg, &chatMsg); err != nil {
-			p.Stop()
-		}
-		player := p.GetTargetPlayer(chatMsg.Index)
-		if player != nil {
-			player.SendChat(chatMsg.Context)
-		}
+	s.RegisterHandle(protocol.C2SCmd_Logout, func(p *Player, msg []byte) {
+		p.Quit(fmt.Errorf("player(%d) logout", p.index))
 	})
+	s.registerChatHandles()
+	s.RegisterMessage(protocol.C2SCmd_Ping, &protocol.C2SPing{}, func(p *Player, msg proto.Message) {
+		p.Send(protocol.S2CCmd_Pong, &protocol.S2CPong{
+			Time: msg.(*protocol.C2SPing).Time,
+		})
+	})
+	s.RegisterMessageRequest(protocol.C2SCmd_Login, &protocol.C2SLogin{}, s.login)
+	s.registerRoomHandles()
+	s.registerPresenceHandles()
 	return s
 }
 
 func main() {
+	maxFrameSize := flag.Int("maxframe", protocol.MaxFrameSize, "max package body size")
+	verbose := flag.Bool("verbose", false, "log every package")
+	sendQueue := flag.Int("sendqueue", 64, "player send queue size")
+	sendPolicy := SendDrop
+	flag.Var(&sendPolicy, "sendpolicy", "when send queue is full: drop, block or disconnect")
+	sendTimeout := flag.Duration("sendtimeout", time.Second, "block timeout of sendpolicy block")
+	heartbeat := flag.Duration("heartbeat", 10*time.Second, "client heartbeat interval, 0 disable")
+	heartbeatMisses := flag.Int("misses", 3, "stop player after missing heartbeats")
+	shutdownTimeout := flag.Duration("shutdowntimeout", 5*time.Second, "wait players drain before force close")
+	users := flag.String("users", "users.txt", "user store file")
+	addUser := flag.String("adduser", "", "add username:password to user store and exit")
+	loginTimeout := flag.Duration("logintimeout", 10*time.Second, "stop player not login in time")
+	guest := flag.Bool("guest", false, "allow login with a client token instead of username")
+	name := flag.String("name", "go_protobuf_test", "server name sent in welcome")
+	offline := flag.String("offline", "offline.db", "offline message store file, empty disable")
+	offlineMax := flag.Int("offlinemax", 100, "max offline message of a user, 0 no limit")
+	offlineTTL := flag.Duration("offlinettl", 7*24*time.Hour, "drop offline message older than it, 0 never")
+	limit := RateLimit{
+		Player:        Rate{PerSecond: 20, Burst: 40},
+		Command:       CmdRates{int32(protocol.C2SCmd_Chat): {PerSecond: 5, Burst: 10}, int32(protocol.C2SCmd_RoomChat): {PerSecond: 5, Burst: 10}},
+		IP:            Rate{PerSecond: 100, Burst: 200},
+		Offense:       Rate{PerSecond: 1, Burst: 10},
+		MaxConnsPerIP: 16,
+	}
+	flag.Var(&limit.Player, "rate", "commands per second:burst of a player, 0 no limit")
+	flag.Var(limit.Command, "cmdrate", "rate:burst of each command of a player, e.g. Chat=5:10,RoomChat=5:10")
+	flag.Var(&limit.IP, "iprate", "commands per second:burst from the same ip, 0 no limit")
+	flag.Var(&limit.Offense, "offense", "throttled commands per second:burst before disconnect, 0 never")
+	flag.IntVar(&limit.MaxConnsPerIP, "maxconnip", 16, "max connections from the same ip, 0 no limit")
+	certFile := flag.String("cert", "", "tls certificate file, empty plain tcp")
+	keyFile := flag.String("key", "", "tls key file")
+	clientCA := flag.String("clientca", "", "verify client certificate by this ca, CN of it can login without password")
+	listen := flag.String("listen", ":7788", "comma separated listen addresses, unix socket as unix:/path")
+	resumeGrace := flag.Duration("resumegrace", 30*time.Second, "keep session of lost connection for resume, 0 disable")
+	resumeBuffer := flag.Int("resumebuffer", 256, "max pushes kept for resume")
+	wsAddr := flag.String("ws", "", "websocket listen address, e.g. :7789, empty disable")
+	wsPath := flag.String("wspath", "/ws", "websocket url path")
+	wsOrigins := flag.String("wsorigin", "", "comma separated origins of pages allowed to open websocket, empty allow any but browsers can not login by certificate")
+	flag.Parse()
+
+	auth, err := NewFileAuthenticator(*users)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *addUser != "" {
+		v := strings.SplitN(*addUser, ":", 2)
+		if len(v) != 2 {
+			log.Fatalln("please input: -adduser username:password")
+		}
+		if err := auth.AddUser(v[0], v[1]); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("add user %s to %s\n", v[0], *users)
+		return
+	}
+
 	app := NewServer()
+	app.SetName(*name)
+	app.SetHeartbeat(*heartbeat, *heartbeatMisses)
+	app.SetMaxFrameSize(*maxFrameSize)
+	app.SetSendQueue(*sendQueue, sendPolicy, *sendTimeout)
+	app.SetShutdownTimeout(*shutdownTimeout)
+	app.SetAuthenticator(auth)
+	app.SetLoginTimeout(*loginTimeout)
+	app.SetGuest(*guest)
+	app.SetRateLimit(limit)
+	app.SetResume(*resumeGrace, *resumeBuffer)
+	if *certFile != "" {
+		config, err := LoadTLSConfig(*certFile, *keyFile, *clientCA)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		app.SetTLS(config)
+	}
+	if *offline != "" {
+		store, err := NewFileMessageStore(*offline, *offlineMax, *offlineTTL)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		app.SetMessageStore(store)
+		defer store.Close()
+	}
+	if *verbose {
+		app.Use(Logger)
+	}
 	go app.HandleSignal()
-	go app.ListenTCP(":7788")
+	for _, addr := range strings.Split(*listen, ",") {
+		if strings.HasPrefix(addr, "unix:") {
+			go app.ListenUnix(strings.TrimPrefix(addr, "unix:"))
+			continue
+		}
+		go app.ListenTCP(addr)
+	}
+	if *wsAddr != "" {
+		if *wsOrigins != "" {
+			app.SetWebSocketOrigins(strings.Split(*wsOrigins, ","))
+		}
+		go app.ListenWebSocket(*wsAddr, *wsPath)
+	}
 	app.Run()
 }
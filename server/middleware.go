@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"runtime/debug"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//HandleFunc dispatch one package from player
+//cmd is the protocol id, reply is only sent back to request
+type HandleFunc func(p *Player, cmd int32, msg []byte) (proto.Message, error)
+
+//Middleware wrap every dispatch
+//return without calling next to short-circuit, or post-process the result of next
+type Middleware func(next HandleFunc) HandleFunc
+
+//Recover turn handle panic into ErrInternal
+func Recover(next HandleFunc) HandleFunc {
+	return func(p *Player, cmd int32, msg []byte) (reply proto.Message, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("player(%d) protocol(%d) panic: %v\n%s", p.GetIndex(), cmd, r, debug.Stack())
+				reply, err = nil, protocol.NewError(protocol.ErrCode_ErrInternal, "protocol(%d) panic", cmd)
+			}
+		}()
+		return next(p, cmd, msg)
+	}
+}
+
+//Logger print every dispatch and its error
+func Logger(next HandleFunc) HandleFunc {
+	return func(p *Player, cmd int32, msg []byte) (proto.Message, error) {
+		reply, err := next(p, cmd, msg)
+		log.Printf("player(%d) protocol(%d) %d bytes, err: %v\n", p.GetIndex(), cmd, len(msg), err)
+		return reply, err
+	}
+}
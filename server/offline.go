@@ -0,0 +1,412 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//MessageStore keep chat for offline user until login
+type MessageStore interface {
+	//Push append msg to user box
+	Push(username string, msg *protocol.OfflineMessage) error
+	//Pop remove and return every msg of user in order
+	Pop(username string) ([]*protocol.OfflineMessage, error)
+	//Requeue put msgs popped but not delivered back in front of user box, in order
+	Requeue(username string, msgs []*protocol.OfflineMessage) error
+}
+
+//UserLookup optional Authenticator interface, report username is a known user
+type UserLookup interface {
+	HasUser(username string) bool
+}
+
+//HasUser implement UserLookup
+func (a *FileAuthenticator) HasUser(username string) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	_, ok := a.users[username]
+	return ok
+}
+
+//FileMessageStore keep every box in memory and append every change to a log file
+//the log is compacted on load and by Sweep, a change that can not be logged is not applied
+type FileMessageStore struct {
+	path  string
+	max   int
+	ttl   time.Duration
+	mutex sync.Mutex
+	store protocol.OfflineStore
+	file  *os.File
+	//size bytes of file, a failed append is truncated back to it
+	size int64
+	//compacted size right after the last compaction
+	compacted int64
+	done      chan struct{}
+}
+
+//legacyStoreTag first byte of the old single OfflineStore file, a log begin with a zero length byte
+const legacyStoreTag = 0x0a
+
+//NewFileMessageStore load boxes from path, a missing file is an empty store
+//max is msg count limit of a box, msg older than ttl is dropped, 0 no limit
+//every box is swept periodically until Close, so boxes of users never login again are dropped too
+func NewFileMessageStore(path string, max int, ttl time.Duration) (*FileMessageStore, error) {
+	s := &FileMessageStore{
+		path: path,
+		max:  max,
+		ttl:  ttl,
+		done: make(chan struct{}),
+	}
+	s.store.Boxes = make(map[string]*protocol.OfflineBox)
+	buff, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := s.load(buff); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for username, box := range s.store.Boxes {
+		s.expire(box)
+		if len(box.Messages) == 0 {
+			delete(s.store.Boxes, username)
+		}
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	log.Printf("load %d offline boxes from %s\n", len(s.store.Boxes), path)
+	go s.sweepLoop()
+	return s, nil
+}
+
+//load replay the log in buff, or the legacy store
+func (s *FileMessageStore) load(buff []byte) error {
+	if len(buff) > 0 && buff[0] == legacyStoreTag {
+		if err := proto.Unmarshal(buff, &s.store); err != nil {
+			return err
+		}
+		if s.store.Boxes == nil {
+			s.store.Boxes = make(map[string]*protocol.OfflineBox)
+		}
+		return nil
+	}
+	for len(buff) > 0 {
+		if len(buff) < protocol.HeadSize {
+			log.Printf("%s: drop %d bytes of a partial record\n", s.path, len(buff))
+			return nil
+		}
+		size := int(binary.BigEndian.Uint32(buff))
+		if len(buff) < protocol.HeadSize+size {
+			log.Printf("%s: drop %d bytes of a partial record\n", s.path, len(buff))
+			return nil
+		}
+		var rec protocol.OfflineRecord
+		if err := proto.Unmarshal(buff[protocol.HeadSize:protocol.HeadSize+size], &rec); err != nil {
+			return err
+		}
+		s.apply(&rec)
+		buff = buff[protocol.HeadSize+size:]
+	}
+	return nil
+}
+
+//apply rec to boxes in memory, must hold mutex
+func (s *FileMessageStore) apply(rec *protocol.OfflineRecord) {
+	box, ok := s.store.Boxes[rec.User]
+	if !ok {
+		if rec.Op == protocol.OfflineOp_OfflinePop {
+			return
+		}
+		box = &protocol.OfflineBox{}
+		s.store.Boxes[rec.User] = box
+	}
+	switch rec.Op {
+	case protocol.OfflineOp_OfflinePush:
+		box.Messages = append(box.Messages, rec.Messages...)
+	case protocol.OfflineOp_OfflinePop:
+		delete(s.store.Boxes, rec.User)
+	case protocol.OfflineOp_OfflineRequeue:
+		box.Messages = append(append([]*protocol.OfflineMessage{}, rec.Messages...), box.Messages...)
+	}
+}
+
+//record frame of rec in the log
+func record(rec *protocol.OfflineRecord) ([]byte, error) {
+	body, err := proto.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	buff := make([]byte, protocol.HeadSize+len(body))
+	binary.BigEndian.PutUint32(buff, uint32(len(body)))
+	copy(buff[protocol.HeadSize:], body)
+	return buff, nil
+}
+
+//append rec to the log then apply it, nothing is changed if it fail, must hold mutex
+func (s *FileMessageStore) append(rec *protocol.OfflineRecord) error {
+	if s.file == nil {
+		return fmt.Errorf("%s is closed", s.path)
+	}
+	buff, err := record(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(buff); err != nil {
+		//a partial record would break every record after it
+		if e := s.file.Truncate(s.size); e != nil {
+			log.Printf("%s: truncate partial record: %v\n", s.path, e)
+		}
+		return err
+	}
+	s.size += int64(len(buff))
+	s.apply(rec)
+	return nil
+}
+
+//compact rewrite the log with one push record a box, must hold mutex
+func (s *FileMessageStore) compact() error {
+	var buff []byte
+	for username, box := range s.store.Boxes {
+		b, err := record(&protocol.OfflineRecord{
+			Op:       protocol.OfflineOp_OfflinePush,
+			User:     username,
+			Messages: box.Messages,
+		})
+		if err != nil {
+			return err
+		}
+		buff = append(buff, b...)
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = f
+	s.size = int64(len(buff))
+	s.compacted = s.size
+	return nil
+}
+
+func (s *FileMessageStore) sweepLoop() {
+	interval := s.ttl
+	if interval <= 0 || interval > time.Hour {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Sweep(); err != nil {
+				log.Printf("sweep offline boxes: %v\n", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+//Sweep drop expired msg of every box and empty boxes, compact the log if it has grown
+func (s *FileMessageStore) Sweep() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	dropped := 0
+	for username, box := range s.store.Boxes {
+		n := len(box.Messages)
+		s.expire(box)
+		dropped += n - len(box.Messages)
+		if len(box.Messages) == 0 {
+			delete(s.store.Boxes, username)
+		}
+	}
+	if dropped > 0 {
+		log.Printf("drop %d expired offline messages\n", dropped)
+	}
+	if dropped == 0 && s.size == s.compacted {
+		return nil
+	}
+	return s.compact()
+}
+
+//Close stop sweeping and close the log
+func (s *FileMessageStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+//expire must hold mutex
+func (s *FileMessageStore) expire(box *protocol.OfflineBox) {
+	if s.ttl <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-s.ttl).UnixNano()
+	i := 0
+	for i < len(box.Messages) && box.Messages[i].Time < deadline {
+		i++
+	}
+	box.Messages = box.Messages[i:]
+}
+
+//Push implement MessageStore
+func (s *FileMessageStore) Push(username string, msg *protocol.OfflineMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	n := 0
+	if box, ok := s.store.Boxes[username]; ok {
+		s.expire(box)
+		n = len(box.Messages)
+	}
+	if s.max > 0 && n >= s.max {
+		return fmt.Errorf("%s offline box full", username)
+	}
+	return s.append(&protocol.OfflineRecord{
+		Op:       protocol.OfflineOp_OfflinePush,
+		User:     username,
+		Messages: []*protocol.OfflineMessage{msg},
+	})
+}
+
+//Pop implement MessageStore, the box is kept if it can not be logged
+func (s *FileMessageStore) Pop(username string) ([]*protocol.OfflineMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	box, ok := s.store.Boxes[username]
+	if !ok {
+		return nil, nil
+	}
+	s.expire(box)
+	if err := s.append(&protocol.OfflineRecord{
+		Op:   protocol.OfflineOp_OfflinePop,
+		User: username,
+	}); err != nil {
+		return nil, err
+	}
+	return box.Messages, nil
+}
+
+//Requeue implement MessageStore, msgs are accepted before so max is not checked
+func (s *FileMessageStore) Requeue(username string, msgs []*protocol.OfflineMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.append(&protocol.OfflineRecord{
+		Op:       protocol.OfflineOp_OfflineRequeue,
+		User:     username,
+		Messages: msgs,
+	})
+}
+
+//SetMessageStore keep chat for offline user, nil disable
+//call before Serve or any Listen
+func (s *Server) SetMessageStore(store MessageStore) {
+	s.store = store
+}
+
+//storeChat keep chat for a known offline user
+func (s *Server) storeChat(p *Player, chat *protocol.C2SChat, status *protocol.S2CChatStatus) {
+	lookup, ok := s.auth.(UserLookup)
+	if s.store == nil || !ok || !lookup.HasUser(chat.User) {
+		status.State = protocol.ChatState_ChatOffline
+		return
+	}
+	s.pushOffline(p, chat, status)
+}
+
+//pushOffline keep chat in the box of chat.User, MessageStore must be set
+func (s *Server) pushOffline(p *Player, chat *protocol.C2SChat, status *protocol.S2CChatStatus) {
+	if err := s.store.Push(chat.User, &protocol.OfflineMessage{
+		From:    p.GetID(),
+		Context: chat.Context,
+		MsgId:   chat.MsgId,
+		Time:    time.Now().UnixNano(),
+	}); err != nil {
+		status.State = protocol.ChatState_ChatRejected
+		status.Reason = err.Error()
+		return
+	}
+	status.State = protocol.ChatState_ChatStored
+}
+
+//flushOffline send stored chat to p after login, what can not be sent is put back in front of the box
+func (s *Server) flushOffline(p *Player) {
+	if s.store == nil {
+		return
+	}
+	id := p.GetID()
+	msgs, err := s.store.Pop(id)
+	if err != nil {
+		log.Printf("player(%d) offline box: %v\n", p.index, err)
+	}
+	for i, msg := range msgs {
+		if err := p.Send(protocol.S2CCmd_ChatMsg, &protocol.S2CChat{
+			Context: msg.Context,
+			MsgId:   msg.MsgId,
+			From:    msg.From,
+			Time:    msg.Time,
+			Offline: true,
+		}); err != nil {
+			log.Printf("player(%d) flush offline: %v, %d left\n", p.index, err, len(msgs)-i)
+			if err := s.store.Requeue(id, msgs[i:]); err != nil {
+				log.Printf("player(%d) requeue offline: %v, %d lost\n", p.index, err, len(msgs)-i)
+			}
+			return
+		}
+		if msg.MsgId != 0 {
+			p.unread.add(receiptKey{msg.From, msg.MsgId})
+		}
+	}
+}
+
+//storeMissed move chats kept for a detached player not resumed in time to its box
+//return how many chats are lost
+func (s *Server) storeMissed(id string, missed [][]byte) int {
+	lost := 0
+	for _, buff := range missed {
+		_, pkg, err := protocol.UnPackPackage(buff, len(buff))
+		if err != nil || protocol.S2CCmd(pkg.Serial) != protocol.S2CCmd_ChatMsg {
+			continue
+		}
+		var chat protocol.S2CChat
+		if err := proto.Unmarshal(pkg.Buff, &chat); err != nil {
+			continue
+		}
+		if s.store == nil || s.store.Push(id, &protocol.OfflineMessage{
+			From:    chat.From,
+			Context: chat.Context,
+			MsgId:   chat.MsgId,
+			Time:    chat.Time,
+		}) != nil {
+			lost++
+		}
+	}
+	return lost
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+func newTestStore(t *testing.T, max int, ttl time.Duration) (*FileMessageStore, func()) {
+	dir, err := ioutil.TempDir("", "offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileMessageStore(filepath.Join(dir, "offline.db"), max, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func msgIDs(msgs []*protocol.OfflineMessage) []uint64 {
+	var ids []uint64
+	for _, msg := range msgs {
+		ids = append(ids, msg.MsgId)
+	}
+	return ids
+}
+
+func chat(id uint64, at time.Time) *protocol.OfflineMessage {
+	return &protocol.OfflineMessage{From: "alice", MsgId: id, Time: at.UnixNano()}
+}
+
+func TestRequeueKeepOrder(t *testing.T) {
+	s, done := newTestStore(t, 3, 0)
+	defer done()
+	now := time.Now()
+	s.Push("bob", chat(1, now))
+	s.Push("bob", chat(2, now))
+	s.Push("bob", chat(3, now))
+	msgs, err := s.Pop("bob")
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("pop %d, %v", len(msgs), err)
+	}
+	//arrive while flushing
+	s.Push("bob", chat(4, now))
+	//box is full with 4 but msgs left are accepted before
+	if err := s.Requeue("bob", msgs[1:]); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = s.Pop("bob")
+	ids := msgIDs(msgs)
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 3 || ids[2] != 4 {
+		t.Fatalf("got %v, want [2 3 4]", ids)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	s, done := newTestStore(t, 0, time.Hour)
+	defer done()
+	now := time.Now()
+	s.Push("bob", chat(1, now.Add(-2*time.Hour)))
+	s.Push("carol", chat(2, now.Add(-2*time.Hour)))
+	s.Push("carol", chat(3, now))
+	if err := s.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.store.Boxes["bob"]; ok {
+		t.Fatal("expired box is kept")
+	}
+	if box := s.store.Boxes["carol"]; box == nil || len(box.Messages) != 1 || box.Messages[0].MsgId != 3 {
+		t.Fatalf("got %v, want message 3", box)
+	}
+}
+
+func TestLogReplay(t *testing.T) {
+	s, done := newTestStore(t, 0, 0)
+	defer done()
+	now := time.Now()
+	s.Push("bob", chat(1, now))
+	s.Push("bob", chat(2, now))
+	s.Push("carol", chat(3, now))
+	msgs, _ := s.Pop("bob")
+	s.Push("bob", chat(4, now))
+	s.Requeue("bob", msgs[1:])
+	s.Pop("carol")
+	//only the change is appended, not the whole store
+	if s.size <= s.compacted {
+		t.Fatal("nothing is appended")
+	}
+	size := s.size
+	s.Push("dave", chat(5, now))
+	if s.size-size > 64 {
+		t.Fatalf("push append %d bytes", s.size-size)
+	}
+	s.Close()
+
+	s, err := NewFileMessageStore(s.path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, ok := s.store.Boxes["carol"]; ok {
+		t.Fatal("popped box is back")
+	}
+	msgs, _ = s.Pop("bob")
+	if ids := msgIDs(msgs); len(ids) != 2 || ids[0] != 2 || ids[1] != 4 {
+		t.Fatalf("got %v, want [2 4]", ids)
+	}
+}
+
+func TestPushRollback(t *testing.T) {
+	s, done := newTestStore(t, 0, 0)
+	defer done()
+	now := time.Now()
+	s.Push("bob", chat(1, now))
+	//writes fail on a read only handle
+	f, err := os.Open(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.file.Close()
+	s.file = f
+	if err := s.Push("bob", chat(2, now)); err == nil {
+		t.Fatal("push succeed without the log")
+	}
+	if msgs, err := s.Pop("bob"); err == nil || msgs != nil {
+		t.Fatalf("pop %v, %v without the log", msgIDs(msgs), err)
+	}
+	if box := s.store.Boxes["bob"]; box == nil || len(box.Messages) != 1 {
+		t.Fatalf("got %v, want message 1 only", box)
+	}
+}
+
+func TestLoadLegacy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "offline.db")
+	buff, _ := proto.Marshal(&protocol.OfflineStore{Boxes: map[string]*protocol.OfflineBox{
+		"bob": {Messages: []*protocol.OfflineMessage{chat(1, time.Now())}},
+	}})
+	if err := ioutil.WriteFile(path, buff, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		//converted to a log on the first load
+		s, err := NewFileMessageStore(path, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		box := s.store.Boxes["bob"]
+		s.Close()
+		if box == nil || len(box.Messages) != 1 {
+			t.Fatalf("load %d: got %v", i, box)
+		}
+	}
+}
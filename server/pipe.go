@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+//ErrPipeClosed PipeListener has been closed
+var ErrPipeClosed = errors.New("pipe listener closed")
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+//PipeListener in memory listener, every Dial is accepted as a net.Pipe
+//drive a Server without binding any port
+type PipeListener struct {
+	ch   chan net.Conn
+	done chan struct{}
+	once sync.Once
+}
+
+//NewPipeListener pass it to Server.Serve, then Dial
+func NewPipeListener() *PipeListener {
+	return &PipeListener{
+		ch:   make(chan net.Conn),
+		done: make(chan struct{}),
+	}
+}
+
+//Dial return client side of a new pipe, block until accepted
+func (l *PipeListener) Dial() (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.ch <- server:
+		return client, nil
+	case <-l.done:
+		return nil, ErrPipeClosed
+	}
+}
+
+//Accept implement net.Listener
+func (l *PipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.ch:
+		return conn, nil
+	case <-l.done:
+		return nil, ErrPipeClosed
+	}
+}
+
+//Close implement net.Listener
+func (l *PipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+//Addr implement net.Listener
+func (l *PipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Version server version sent in welcome
+const Version = "1.0.0"
+
+//MaxNameSize display name length in characters
+const MaxNameSize = 32
+
+//SetName server name sent in welcome
+func (s *Server) SetName(name string) {
+	s.name = name
+}
+
+//GetInfo player info for roster and presence
+func (p *Player) GetInfo() *protocol.PlayerInfo {
+	return &protocol.PlayerInfo{
+		Id:   p.GetID(),
+		Name: p.GetName(),
+	}
+}
+
+//rosterOverhead bytes of a frame besides players, Package fields, more and total
+const rosterOverhead = 64
+
+//GetRoster one page of login players whose id is greater than after, order by id
+//a page fit in a frame of maxFrameSize, ask the next page with the last id if more
+func (s *Server) GetRoster(after string) *protocol.S2CRoster {
+	roster := &protocol.S2CRoster{}
+	var players []*protocol.PlayerInfo
+	s.ids.Range(func(p *Player) bool {
+		roster.Total++
+		if info := p.GetInfo(); info.Id > after {
+			players = append(players, info)
+		}
+		return true
+	})
+	sort.Slice(players, func(i, j int) bool { return players[i].Id < players[j].Id })
+	size := rosterOverhead
+	for i, info := range players {
+		n := proto.Size(info)
+		size += 1 + proto.SizeVarint(uint64(n)) + n
+		if size > s.maxFrameSize && i > 0 {
+			roster.More = true
+			break
+		}
+		roster.Players = append(roster.Players, info)
+	}
+	return roster
+}
+
+//welcome send welcome and roster to p, tell others p is online
+func (s *Server) welcome(p *Player) {
+	s.welcomeBack(p)
+	s.brocastPresence(p, true)
+}
+
+//welcomeBack send welcome and roster to p, others never know p is gone
+func (s *Server) welcomeBack(p *Player) {
+	if err := p.Send(protocol.S2CCmd_Welcome, &protocol.S2CWelcome{
+		Self:       p.GetInfo(),
+		ServerName: s.name,
+		Version:    Version,
+		Time:       time.Now().UnixNano(),
+	}); err != nil {
+		log.Printf("player(%d) welcome: %v\n", p.index, err)
+	}
+	//the client ask the rest pages one by one, a large roster never flood the send queue
+	if err := p.Send(protocol.S2CCmd_Roster, s.GetRoster("")); err != nil {
+		log.Printf("player(%d) roster: %v\n", p.index, err)
+	}
+}
+
+//brocastPresence tell every login player except p
+func (s *Server) brocastPresence(p *Player, online bool) {
+	presence := &protocol.S2CPresence{
+		Player: p.GetInfo(),
+		Online: online,
+	}
+	s.ids.Range(func(other *Player) bool {
+		if other == p {
+			return true
+		}
+		if err := other.Send(protocol.S2CCmd_Presence, presence); err != nil {
+			log.Printf("player(%d) presence: %v\n", other.index, err)
+		}
+		return true
+	})
+}
+
+//SetNick change display name of p, others see p online again with the new name
+func (s *Server) SetNick(p *Player, name string) (*protocol.PlayerInfo, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameSize {
+		return nil, protocol.NewError(protocol.ErrCode_ErrBadRequest, "name must be 1 to %d characters", MaxNameSize)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return nil, protocol.NewError(protocol.ErrCode_ErrBadRequest, "name %q has control character", name)
+	}
+	p.setName(name)
+	log.Printf("player(%d) %s nick %s\n", p.index, p.GetID(), name)
+	s.brocastPresence(p, true)
+	return p.GetInfo(), nil
+}
+
+func (s *Server) registerPresenceHandles() {
+	s.RegisterMessageRequest(protocol.C2SCmd_PlayerList, &protocol.C2SPlayerList{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.GetRoster(msg.(*protocol.C2SPlayerList).After), nil
+	})
+	s.RegisterMessageRequest(protocol.C2SCmd_Nick, &protocol.C2SNick{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.SetNick(p, msg.(*protocol.C2SNick).Name)
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+func TestRosterPages(t *testing.T) {
+	s := newBenchServer(0)
+	s.maxFrameSize = protocol.MaxFrameSize
+	const n = 5000
+	for i := 0; i < n; i++ {
+		p := &Player{s: s}
+		s.addPlayer(p)
+		p.setLogin(fmt.Sprintf("guest-%05d", i), strings.Repeat("名", MaxNameSize))
+		s.bindID(p)
+	}
+	seen := make(map[string]bool)
+	pages := 0
+	for after := ""; ; pages++ {
+		page := s.GetRoster(after)
+		buff, err := protocol.Pack(int32(protocol.S2CCmd_Roster), page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(buff)-protocol.HeadSize > s.maxFrameSize {
+			t.Fatalf("page %d is %d bytes", pages, len(buff))
+		}
+		if page.Total != n {
+			t.Fatalf("total %d, want %d", page.Total, n)
+		}
+		for _, info := range page.Players {
+			if info.Id <= after || seen[info.Id] {
+				t.Fatalf("page %d: %s after %s", pages, info.Id, after)
+			}
+			seen[info.Id] = true
+			after = info.Id
+		}
+		if !page.More {
+			break
+		}
+	}
+	if len(seen) != n || pages < 2 {
+		t.Fatalf("got %d players in %d pages", len(seen), pages+1)
+	}
+}
+
+func TestSendFrameLimit(t *testing.T) {
+	s := newBenchServer(0)
+	s.maxFrameSize = 16
+	p := &Player{s: s, chSend: make(chan []byte, 1)}
+	err := p.Send(protocol.S2CCmd_Result, &protocol.S2CResult{Context: "much longer than sixteen bytes"})
+	if err != protocol.ErrFrameTooLarge {
+		t.Fatalf("got %v, want ErrFrameTooLarge", err)
+	}
+	if len(p.chSend) != 0 {
+		t.Fatal("too large frame is queued")
+	}
+	if err := p.Send(protocol.S2CCmd_Result, &protocol.S2CResult{Context: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import "sync"
+
+//shardCount players are split into shards, each with its own lock
+const shardCount = 32
+
+//rangeBuffers reused by Range to copy a shard, broadcast does not allocate
+var rangeBuffers = sync.Pool{
+	New: func() interface{} {
+		return new([]*Player)
+	},
+}
+
+//putRangeBuffer clear every copied player before reuse, players left are not kept reachable by the pool
+func putRangeBuffer(buff *[]*Player) {
+	list := (*buff)[:cap(*buff)]
+	for i := range list {
+		list[i] = nil
+	}
+	*buff = list[:0]
+	rangeBuffers.Put(buff)
+}
+
+//rangeShard call f for every player copied to buff, return false if f stop
+func rangeShard(buff []*Player, f func(*Player) bool) bool {
+	for _, p := range buff {
+		if !f(p) {
+			return false
+		}
+	}
+	return true
+}
+
+type playerShard struct {
+	mutex   sync.RWMutex
+	players map[uint64]*Player
+}
+
+//playerMap players by connection index
+//accept, leave and lookup of players in different shards never wait on each other
+type playerMap struct {
+	shards [shardCount]playerShard
+}
+
+func newPlayerMap() *playerMap {
+	m := &playerMap{}
+	for i := range m.shards {
+		m.shards[i].players = make(map[uint64]*Player)
+	}
+	return m
+}
+
+func (m *playerMap) shard(index uint64) *playerShard {
+	return &m.shards[index%shardCount]
+}
+
+//Load player of index
+func (m *playerMap) Load(index uint64) (*Player, bool) {
+	shard := m.shard(index)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	p, ok := shard.players[index]
+	return p, ok
+}
+
+//Store p at p.index
+func (m *playerMap) Store(p *Player) {
+	shard := m.shard(p.index)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	shard.players[p.index] = p
+}
+
+//Delete return false if index is not found
+func (m *playerMap) Delete(index uint64) bool {
+	shard := m.shard(index)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if _, ok := shard.players[index]; !ok {
+		return false
+	}
+	delete(shard.players, index)
+	return true
+}
+
+//Range call f for every player, stop if f return false
+//each shard is copied to a pooled buffer and f is called without lock
+//so a slow f such as a blocking Send never hold up login and leave
+func (m *playerMap) Range(f func(*Player) bool) {
+	buff := rangeBuffers.Get().(*[]*Player)
+	defer putRangeBuffer(buff)
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mutex.RLock()
+		list := (*buff)[:0]
+		for _, p := range shard.players {
+			list = append(list, p)
+		}
+		shard.mutex.RUnlock()
+		*buff = list
+		if !rangeShard(list, f) {
+			return
+		}
+	}
+}
+
+type idShard struct {
+	mutex   sync.RWMutex
+	players map[string]*Player
+}
+
+//idMap login players by user id, sharded like playerMap
+type idMap struct {
+	shards [shardCount]idShard
+}
+
+func newIDMap() *idMap {
+	m := &idMap{}
+	for i := range m.shards {
+		m.shards[i].players = make(map[string]*Player)
+	}
+	return m
+}
+
+//shard by fnv-1a hash of id
+func (m *idMap) shard(id string) *idShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return &m.shards[h%shardCount]
+}
+
+//Load player of id
+func (m *idMap) Load(id string) (*Player, bool) {
+	shard := m.shard(id)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	p, ok := shard.players[id]
+	return p, ok
+}
+
+//Swap store p at id, return the player stored before
+func (m *idMap) Swap(id string, p *Player) *Player {
+	shard := m.shard(id)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	old := shard.players[id]
+	shard.players[id] = p
+	return old
+}
+
+//CompareAndDelete delete id only if it is stored with p
+func (m *idMap) CompareAndDelete(id string, p *Player) bool {
+	shard := m.shard(id)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if shard.players[id] != p {
+		return false
+	}
+	delete(shard.players, id)
+	return true
+}
+
+//Range same as playerMap.Range
+func (m *idMap) Range(f func(*Player) bool) {
+	buff := rangeBuffers.Get().(*[]*Player)
+	defer putRangeBuffer(buff)
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mutex.RLock()
+		list := (*buff)[:0]
+		for _, p := range shard.players {
+			list = append(list, p)
+		}
+		shard.mutex.RUnlock()
+		*buff = list
+		if !rangeShard(list, f) {
+			return
+		}
+	}
+}
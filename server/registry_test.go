@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+//registry what the mixed load uses of a player registry
+type registry interface {
+	Load(index uint64) (*Player, bool)
+	Store(p *Player)
+	Delete(index uint64) bool
+	Range(f func(*Player) bool)
+}
+
+//mutexRegistry the map with one RWMutex replaced by playerMap, kept to compare
+//Range copy the players first like getPlayerList did
+type mutexRegistry struct {
+	mutex   sync.RWMutex
+	players map[uint64]*Player
+}
+
+func (m *mutexRegistry) Load(index uint64) (*Player, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	p, ok := m.players[index]
+	return p, ok
+}
+
+func (m *mutexRegistry) Store(p *Player) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.players[p.index] = p
+}
+
+func (m *mutexRegistry) Delete(index uint64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.players[index]; !ok {
+		return false
+	}
+	delete(m.players, index)
+	return true
+}
+
+func (m *mutexRegistry) Range(f func(*Player) bool) {
+	m.mutex.RLock()
+	list := make([]*Player, 0, len(m.players))
+	for _, p := range m.players {
+		list = append(list, p)
+	}
+	m.mutex.RUnlock()
+	for _, p := range list {
+		if !f(p) {
+			return
+		}
+	}
+}
+
+func TestPlayerMap(t *testing.T) {
+	m := newPlayerMap()
+	for i := uint64(1); i <= 100; i++ {
+		m.Store(&Player{index: i})
+	}
+	if p, ok := m.Load(42); !ok || p.index != 42 {
+		t.Fatal("stored player is not found")
+	}
+	if !m.Delete(42) || m.Delete(42) {
+		t.Fatal("player is deleted twice")
+	}
+	n := 0
+	m.Range(func(p *Player) bool {
+		//f is called without lock and may delete
+		m.Delete(p.index)
+		n++
+		return true
+	})
+	if n != 99 {
+		t.Fatalf("range %d players, want 99", n)
+	}
+	m.Range(func(p *Player) bool {
+		t.Fatal("deleted player in range")
+		return false
+	})
+}
+
+func TestIDMapRangeUnlocked(t *testing.T) {
+	m := newIDMap()
+	p := &Player{index: 1}
+	m.Swap("bob", p)
+	m.Range(func(other *Player) bool {
+		//writers of the same shard must not wait for f
+		if !m.CompareAndDelete("bob", other) {
+			t.Fatal("bob is not deleted")
+		}
+		m.Swap("bob", p)
+		return true
+	})
+}
+
+func TestPutRangeBuffer(t *testing.T) {
+	list := []*Player{{index: 1}, {index: 2}, {index: 3}}
+	buff := &list
+	*buff = (*buff)[:1]
+	putRangeBuffer(buff)
+	for i, p := range list {
+		if p != nil {
+			t.Fatalf("player %d is kept by the pool", i)
+		}
+	}
+	if len(*buff) != 0 {
+		t.Fatal("buffer is not reset")
+	}
+}
+
+//mixedLoad per 100 ops: 90 chat lookups, 8 join and leave, 2 broadcasts
+func mixedLoad(b *testing.B, r registry, players int) {
+	for i := 1; i <= players; i++ {
+		r.Store(&Player{index: uint64(i)})
+	}
+	var next uint64 = uint64(players)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			switch op := i % 100; {
+			case op < 90:
+				r.Load(uint64(i%players + 1))
+			case op < 98:
+				index := atomic.AddUint64(&next, 1)
+				r.Store(&Player{index: index})
+				r.Delete(index)
+			default:
+				r.Range(func(p *Player) bool { return true })
+			}
+		}
+	})
+}
+
+func BenchmarkRegistry(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("sharded/%d", n), func(b *testing.B) {
+			mixedLoad(b, newPlayerMap(), n)
+		})
+		b.Run(fmt.Sprintf("mutex/%d", n), func(b *testing.B) {
+			mixedLoad(b, &mutexRegistry{players: make(map[uint64]*Player)}, n)
+		})
+	}
+}
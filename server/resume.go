@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+//SetResume keep the session of a login player for grace after its connection lost
+//pushes are kept up to buffer and rooms are kept, a login with the same id in grace resumes it
+//grace 0 disable, call before Serve or any Listen
+func (s *Server) SetResume(grace time.Duration, buffer int) {
+	s.resumeGrace = grace
+	s.resumeBuffer = buffer
+}
+
+//IsDetached connection lost, session is kept for resume
+func (p *Player) IsDetached() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.detached
+}
+
+//detach begin keeping pushes, return false if resume is disabled, server is closing, p quit or p is replaced
+//p taken over by a new login is detached already
+func (p *Player) detach() bool {
+	if p.IsDetached() {
+		return true
+	}
+	if p.s.resumeGrace <= 0 || !p.IsLogin() || p.s.isClosing() {
+		return false
+	}
+	if other, ok := p.s.GetPlayerByID(p.GetID()); !ok || other != p {
+		return false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.quit {
+		return false
+	}
+	p.detached = true
+	return true
+}
+
+//keep push for resume, return false if p is not detached
+func (p *Player) keep(buff []byte) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.detached {
+		return false, nil
+	}
+	if len(p.missed) >= p.s.resumeBuffer {
+		return true, ErrSendQueueFull
+	}
+	p.missed = append(p.missed, buff)
+	return true, nil
+}
+
+//keepQueue keep what left in send queue
+func (p *Player) keepQueue() {
+	for {
+		select {
+		case buff := <-p.chSend:
+			p.keep(buff)
+		default:
+			return
+		}
+	}
+}
+
+//waitResume p leave if not resumed in grace
+func (p *Player) waitResume() {
+	p.keepQueue()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	//taken over and resumed already
+	if !p.detached {
+		return
+	}
+	log.Printf("player(%d) %s detached, %d pushes kept\n", p.index, p.id, len(p.missed))
+	p.expire = time.AfterFunc(p.s.resumeGrace, func() {
+		p.mutex.Lock()
+		if !p.detached {
+			p.mutex.Unlock()
+			return
+		}
+		p.detached = false
+		missed := p.missed
+		p.missed = nil
+		id := p.id
+		p.mutex.Unlock()
+		lost := p.s.storeMissed(id, missed)
+		log.Printf("player(%d) %s resume timeout, %d chats lost\n", p.index, id, lost)
+		p.s.leave(p)
+	})
+}
+
+//takeover detach old for a new login with the same id, return false if old can not be resumed
+//old is still connected, its connection may be half open and not known lost until heartbeat timeout
+func (s *Server) takeover(old *Player) bool {
+	if s.resumeGrace <= 0 {
+		return false
+	}
+	old.mutex.Lock()
+	if old.detached || old.quit {
+		defer old.mutex.Unlock()
+		return old.detached
+	}
+	old.detached = true
+	//what is queued but not written is kept before pushes from now on
+	for len(old.missed) < s.resumeBuffer {
+		select {
+		case buff := <-old.chSend:
+			old.missed = append(old.missed, buff)
+			continue
+		default:
+		}
+		break
+	}
+	old.mutex.Unlock()
+	old.stop(fmt.Errorf("player(%d) %s taken over", old.index, old.GetID()))
+	return true
+}
+
+//resumeTo stop keeping pushes of p, return them, false if p is not detached
+func (p *Player) resumeTo() ([][]byte, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.detached {
+		return nil, false
+	}
+	p.detached = false
+	if p.expire != nil {
+		p.expire.Stop()
+	}
+	missed := p.missed
+	p.missed = nil
+	return missed, true
+}
+
+//resume move rooms and kept pushes of old to p after welcome, return false if old can not be resumed
+func (s *Server) resume(old, p *Player) bool {
+	missed, ok := old.resumeTo()
+	if !ok {
+		return false
+	}
+	p.setName(old.GetName())
+	old.unread.moveTo(&p.unread)
+	s.moveRooms(old, p)
+	s.DelPlayer(old.index)
+	s.welcomeBack(p)
+	for _, buff := range missed {
+		if err := p.queue(buff); err != nil {
+			log.Printf("player(%d) resume: %v\n", p.index, err)
+			break
+		}
+	}
+	log.Printf("player(%d) resume player(%d) %s, %d pushes\n", p.index, old.index, p.GetID(), len(missed))
+	return true
+}
+
+//moveRooms p take the place of old in every room
+func (s *Server) moveRooms(old, p *Player) {
+	s.roomMutex.Lock()
+	defer s.roomMutex.Unlock()
+	for _, members := range s.rooms {
+		if _, ok := members[old.index]; ok {
+			delete(members, old.index)
+			members[p.index] = p
+		}
+	}
+}
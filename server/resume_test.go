@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestQuitNotDetach(t *testing.T) {
+	s := newBenchServer(0)
+	s.resumeGrace = time.Minute
+	for _, quit := range []bool{false, true} {
+		p := &Player{s: s, chStop: make(chan error, 1), chDone: make(chan struct{})}
+		p.setLogin("bob", "bob")
+		s.bindID(p)
+		if quit {
+			p.Quit(fmt.Errorf("logout"))
+		} else {
+			p.Stop()
+		}
+		if got := p.detach(); got == quit {
+			t.Errorf("quit %v: detach %v", quit, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//MaxRoomName max length of room name
+const MaxRoomName = 32
+
+func checkRoomName(name string) error {
+	if name == "" || len(name) > MaxRoomName {
+		return protocol.NewError(protocol.ErrCode_ErrBadRequest, "room name length must be 1-%d", MaxRoomName)
+	}
+	return nil
+}
+
+func roomInfo(name string, members map[uint64]*Player) *protocol.S2CRoom {
+	room := &protocol.S2CRoom{Name: name}
+	for _, p := range members {
+		room.Members = append(room.Members, p.GetID())
+	}
+	sort.Strings(room.Members)
+	return room
+}
+
+//CreateRoom create room and p join it
+func (s *Server) CreateRoom(name string, p *Player) (*protocol.S2CRoom, error) {
+	if err := checkRoomName(name); err != nil {
+		return nil, err
+	}
+	s.roomMutex.Lock()
+	defer s.roomMutex.Unlock()
+	if _, ok := s.rooms[name]; ok {
+		return nil, protocol.NewError(protocol.ErrCode_ErrExist, "room %s exsit", name)
+	}
+	s.rooms[name] = map[uint64]*Player{p.index: p}
+	log.Printf("player(%d) create room %s\n", p.index, name)
+	return roomInfo(name, s.rooms[name]), nil
+}
+
+//JoinRoom p join an exsit room
+func (s *Server) JoinRoom(name string, p *Player) (*protocol.S2CRoom, error) {
+	s.roomMutex.Lock()
+	defer s.roomMutex.Unlock()
+	members, ok := s.rooms[name]
+	if !ok {
+		return nil, protocol.NewError(protocol.ErrCode_ErrNotFound, "room %s non-exsit", name)
+	}
+	members[p.index] = p
+	return roomInfo(name, members), nil
+}
+
+//LeaveRoom p leave room, room is removed when nobody in it
+func (s *Server) LeaveRoom(name string, p *Player) (*protocol.S2CRoom, error) {
+	s.roomMutex.Lock()
+	defer s.roomMutex.Unlock()
+	members, ok := s.rooms[name]
+	if !ok {
+		return nil, protocol.NewError(protocol.ErrCode_ErrNotFound, "room %s non-exsit", name)
+	}
+	if _, ok := members[p.index]; !ok {
+		return nil, protocol.NewError(protocol.ErrCode_ErrNotFound, "not in room %s", name)
+	}
+	s.leaveRoom(name, p.index)
+	return roomInfo(name, members), nil
+}
+
+//leaveRoom must hold roomMutex
+func (s *Server) leaveRoom(name string, index uint64) {
+	members := s.rooms[name]
+	delete(members, index)
+	if len(members) == 0 {
+		delete(s.rooms, name)
+		log.Printf("room %s removed\n", name)
+	}
+}
+
+func (s *Server) leaveAllRooms(index uint64) {
+	s.roomMutex.Lock()
+	defer s.roomMutex.Unlock()
+	for name, members := range s.rooms {
+		if _, ok := members[index]; ok {
+			s.leaveRoom(name, index)
+		}
+	}
+}
+
+//GetRoomList every room and members
+func (s *Server) GetRoomList() *protocol.S2CRoomList {
+	s.roomMutex.RLock()
+	defer s.roomMutex.RUnlock()
+	list := &protocol.S2CRoomList{}
+	for name, members := range s.rooms {
+		list.Rooms = append(list.Rooms, roomInfo(name, members))
+	}
+	sort.Slice(list.Rooms, func(i, j int) bool { return list.Rooms[i].Name < list.Rooms[j].Name })
+	return list
+}
+
+func (s *Server) getRoomMembers(name string) (map[uint64]*Player, bool) {
+	s.roomMutex.RLock()
+	defer s.roomMutex.RUnlock()
+	members, ok := s.rooms[name]
+	if !ok {
+		return nil, false
+	}
+	copied := make(map[uint64]*Player, len(members))
+	for index, p := range members {
+		copied[index] = p
+	}
+	return copied, true
+}
+
+//RoomChat send msg to every member except p
+func (s *Server) RoomChat(name string, p *Player, context string) error {
+	members, ok := s.getRoomMembers(name)
+	if !ok {
+		return protocol.NewError(protocol.ErrCode_ErrNotFound, "room %s non-exsit", name)
+	}
+	if _, ok := members[p.index]; !ok {
+		return protocol.NewError(protocol.ErrCode_ErrNotFound, "not in room %s", name)
+	}
+	msg := &protocol.S2CRoomChat{
+		Room:    name,
+		From:    p.GetID(),
+		Context: context,
+	}
+	for index, member := range members {
+		if index == p.index {
+			continue
+		}
+		if err := member.Send(protocol.S2CCmd_RoomMsg, msg); err != nil {
+			log.Printf("player(%d) room %s: %v\n", index, name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) registerRoomHandles() {
+	s.RegisterMessageRequest(protocol.C2SCmd_RoomCreate, &protocol.C2SRoom{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.CreateRoom(msg.(*protocol.C2SRoom).Name, p)
+	})
+	s.RegisterMessageRequest(protocol.C2SCmd_RoomJoin, &protocol.C2SRoom{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.JoinRoom(msg.(*protocol.C2SRoom).Name, p)
+	})
+	s.RegisterMessageRequest(protocol.C2SCmd_RoomLeave, &protocol.C2SRoom{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.LeaveRoom(msg.(*protocol.C2SRoom).Name, p)
+	})
+	s.RegisterMessageRequest(protocol.C2SCmd_RoomList, &protocol.C2SRoomList{}, func(p *Player, msg proto.Message) (proto.Message, error) {
+		return s.GetRoomList(), nil
+	})
+	s.RegisterMessage(protocol.C2SCmd_RoomChat, &protocol.C2SRoomChat{}, func(p *Player, msg proto.Message) {
+		chat := msg.(*protocol.C2SRoomChat)
+		err := s.RoomChat(chat.Room, p, chat.Context)
+		if err == nil {
+			return
+		}
+		log.Printf("player(%d) room chat: %v\n", p.index, err)
+		//room chat has no reply, tell the sender like throttling does
+		if e, ok := err.(*protocol.S2CError); ok {
+			if err := p.Send(protocol.S2CCmd_Error, e); err != nil && err != ErrPlayerStopped {
+				log.Printf("player(%d) room chat error: %v\n", p.index, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//SendPolicy what to do when player send queue is full
+type SendPolicy int
+
+const (
+	//SendDrop drop the package at once
+	SendDrop SendPolicy = iota
+	//SendBlock wait for the queue until timeout, then drop
+	SendBlock
+	//SendDisconnect stop the player
+	SendDisconnect
+)
+
+var sendPolicyName = []string{"drop", "block", "disconnect"}
+
+var (
+	//ErrSendQueueFull package dropped
+	ErrSendQueueFull = errors.New("send queue full")
+	//ErrPlayerStopped player has left
+	ErrPlayerStopped = errors.New("player stopped")
+)
+
+func (sp SendPolicy) String() string {
+	if int(sp) < len(sendPolicyName) {
+		return sendPolicyName[sp]
+	}
+	return fmt.Sprintf("SendPolicy(%d)", int(sp))
+}
+
+//Set implement flag.Value
+func (sp *SendPolicy) Set(value string) error {
+	for i, name := range sendPolicyName {
+		if strings.EqualFold(name, value) {
+			*sp = SendPolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("send policy must be one of %s", strings.Join(sendPolicyName, ","))
+}
+
+//Send queue msg to player, never write conn directly
+func (p *Player) Send(serial protocol.S2CCmd, msg proto.Message) error {
+	return p.send(serial, 0, msg)
+}
+
+func (p *Player) send(serial protocol.S2CCmd, request uint32, msg proto.Message) error {
+	buff, err := protocol.PackRequest(int32(serial), request, msg)
+	if err != nil {
+		return err
+	}
+	//clients refuse the same size as server, such a frame would cost a reconnect
+	if len(buff)-protocol.HeadSize > p.s.maxFrameSize {
+		return protocol.ErrFrameTooLarge
+	}
+	//reply is useless to a resumed connection
+	if request == 0 && p.s.resumeGrace > 0 {
+		if kept, err := p.keep(buff); kept {
+			return err
+		}
+	}
+	return p.queue(buff)
+}
+
+//queue packed buff, never write conn directly
+func (p *Player) queue(buff []byte) error {
+	select {
+	case p.chSend <- buff:
+		return nil
+	case <-p.chDone:
+		return ErrPlayerStopped
+	default:
+	}
+	switch p.s.sendPolicy {
+	case SendBlock:
+		timer := time.NewTimer(p.s.sendTimeout)
+		defer timer.Stop()
+		select {
+		case p.chSend <- buff:
+			return nil
+		case <-p.chDone:
+			return ErrPlayerStopped
+		case <-timer.C:
+		}
+	case SendDisconnect:
+		p.stop(fmt.Errorf("player(%d) send queue full", p.index))
+	}
+	return ErrSendQueueFull
+}
+
+//write is the only goroutine write conn
+func (p *Player) write() {
+	for {
+		select {
+		case buff := <-p.chSend:
+			if err := p.conn.WriteFrame(buff); err != nil {
+				p.stop(fmt.Errorf("player(%d) write: %v", p.index, err))
+				return
+			}
+		case <-p.chClosing:
+			p.flush()
+			return
+		case <-p.chDone:
+			return
+		}
+	}
+}
+
+//flush write what left in queue then stop player
+func (p *Player) flush() {
+	for {
+		select {
+		case buff := <-p.chSend:
+			if err := p.conn.WriteFrame(buff); err != nil {
+				p.stop(fmt.Errorf("player(%d) write: %v", p.index, err))
+				return
+			}
+		default:
+			p.Quit(fmt.Errorf("player(%d) server shutdown", p.index))
+			return
+		}
+	}
+}
+
+//SetSendQueue size of every player send queue and the policy when it is full
+//timeout only used by SendBlock, call before Serve or any Listen
+func (s *Server) SetSendQueue(size int, policy SendPolicy, timeout time.Duration) {
+	s.sendQueueSize = size
+	s.sendPolicy = policy
+	s.sendTimeout = timeout
+	log.Printf("send queue %d, policy %s, timeout %s\n", size, policy, timeout)
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//testServer a Server running with its handles, players connect over a PipeListener
+type testServer struct {
+	*Server
+	l *PipeListener
+}
+
+//startServer call config before Run, guest login is allowed
+func startServer(t *testing.T, config func(s *Server)) *testServer {
+	s := NewServer()
+	s.SetGuest(true)
+	if config != nil {
+		config(s)
+	}
+	l := NewPipeListener()
+	go s.Run()
+	go s.Serve(l)
+	return &testServer{Server: s, l: l}
+}
+
+//stop Run and wait it shutdown
+func (ts *testServer) stop() {
+	ts.Server.stop(fmt.Errorf("test done"))
+	ts.l.Close()
+}
+
+//testConn one player speaking raw protocol
+type testConn struct {
+	t       *testing.T
+	conn    net.Conn
+	enc     *protocol.Encoder
+	dec     *protocol.Decoder
+	request uint32
+	//pushes read while waiting for a reply
+	pushes []*protocol.Package
+}
+
+func (ts *testServer) dial(t *testing.T) *testConn {
+	conn, err := ts.l.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testConn{
+		t:    t,
+		conn: conn,
+		enc:  protocol.NewEncoder(conn),
+		dec:  protocol.NewDecoder(conn),
+	}
+}
+
+func (c *testConn) read() (*protocol.Package, error) {
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return c.dec.Decode()
+}
+
+func (c *testConn) send(cmd protocol.C2SCmd, msg proto.Message) {
+	if err := c.enc.Encode(int32(cmd), msg); err != nil {
+		c.t.Fatalf("send %s: %v", cmd, err)
+	}
+}
+
+//call return the reply error as *protocol.S2CError
+func (c *testConn) call(cmd protocol.C2SCmd, req, resp proto.Message) error {
+	c.request++
+	if err := c.enc.EncodeRequest(int32(cmd), c.request, req); err != nil {
+		return err
+	}
+	for {
+		pkg, err := c.read()
+		if err != nil {
+			return err
+		}
+		if pkg.Request != c.request {
+			c.pushes = append(c.pushes, pkg)
+			continue
+		}
+		if protocol.S2CCmd(pkg.Serial) == protocol.S2CCmd_Error {
+			var e protocol.S2CError
+			proto.Unmarshal(pkg.Buff, &e)
+			return &e
+		}
+		return proto.Unmarshal(pkg.Buff, resp)
+	}
+}
+
+//wait the next push of cmd, other pushes are skipped
+func (c *testConn) wait(cmd protocol.S2CCmd, msg proto.Message) {
+	for {
+		var pkg *protocol.Package
+		if len(c.pushes) > 0 {
+			pkg, c.pushes = c.pushes[0], c.pushes[1:]
+		} else {
+			var err error
+			if pkg, err = c.read(); err != nil {
+				c.t.Fatalf("wait %s: %v", cmd, err)
+			}
+		}
+		if protocol.S2CCmd(pkg.Serial) == cmd {
+			if err := proto.Unmarshal(pkg.Buff, msg); err != nil {
+				c.t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+//closed read until the server close the connection
+func (c *testConn) closed() bool {
+	for {
+		if _, err := c.read(); err != nil {
+			e, ok := err.(net.Error)
+			return !ok || !e.Timeout()
+		}
+	}
+}
+
+func (c *testConn) login(token string) *protocol.S2CLogin {
+	var reply protocol.S2CLogin
+	if err := c.call(protocol.C2SCmd_Login, &protocol.C2SLogin{Token: token}, &reply); err != nil {
+		c.t.Fatalf("login: %v", err)
+	}
+	return &reply
+}
+
+const testToken = "0123456789abcdef"
+
+func TestTakeoverLiveSession(t *testing.T) {
+	ts := startServer(t, func(s *Server) { s.SetResume(time.Minute, 16) })
+	defer ts.stop()
+	old := ts.dial(t)
+	defer old.conn.Close()
+	old.login(testToken)
+	var room protocol.S2CRoom
+	if err := old.call(protocol.C2SCmd_RoomCreate, &protocol.C2SRoom{Name: "lobby"}, &room); err != nil {
+		t.Fatal(err)
+	}
+
+	//old connection is still alive, the server does not know it is lost
+	c := ts.dial(t)
+	defer c.conn.Close()
+	if reply := c.login(testToken); !reply.Resumed {
+		t.Fatal("live session is not resumed")
+	}
+	var list protocol.S2CRoomList
+	if err := c.call(protocol.C2SCmd_RoomList, &protocol.C2SRoomList{}, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Rooms) != 1 || list.Rooms[0].Name != "lobby" || len(list.Rooms[0].Members) != 1 {
+		t.Fatalf("got rooms %v, want lobby", list.Rooms)
+	}
+	if !old.closed() {
+		t.Fatal("old connection is not closed")
+	}
+}
+
+const otherToken = "fedcba9876543210"
+
+func TestChatEndToEnd(t *testing.T) {
+	ts := startServer(t, nil)
+	defer ts.stop()
+	bob := ts.dial(t)
+	defer bob.conn.Close()
+	alice := ts.dial(t)
+	defer alice.conn.Close()
+	bob.login(testToken)
+	aliceID := alice.login(otherToken).Id
+
+	bob.send(protocol.C2SCmd_Chat, &protocol.C2SChat{User: aliceID, Context: "hi", MsgId: 1})
+	var chat protocol.S2CChat
+	alice.wait(protocol.S2CCmd_ChatMsg, &chat)
+	if chat.Context != "hi" || chat.From != guestID(testToken) {
+		t.Fatalf("alice got %v", &chat)
+	}
+	var status protocol.S2CChatStatus
+	bob.wait(protocol.S2CCmd_ChatStatus, &status)
+	if status.MsgId != 1 || status.State != protocol.ChatState_ChatDelivered {
+		t.Fatalf("bob got status %v", &status)
+	}
+
+	//room chat has no reply, a wrong room is told by an error push
+	bob.send(protocol.C2SCmd_RoomChat, &protocol.C2SRoomChat{Room: "nope", Context: "hi"})
+	var e protocol.S2CError
+	bob.wait(protocol.S2CCmd_Error, &e)
+	if e.Code != protocol.ErrCode_ErrNotFound {
+		t.Fatalf("room chat got %v", &e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go ts.Shutdown(ctx)
+	for _, c := range []*testConn{bob, alice} {
+		var shutdown protocol.S2CShutdown
+		c.wait(protocol.S2CCmd_Shutdown, &shutdown)
+		if !c.closed() {
+			t.Fatal("connection is not closed by shutdown")
+		}
+	}
+}
+
+func TestRequireLogin(t *testing.T) {
+	ts := startServer(t, func(s *Server) { s.SetLoginTimeout(100 * time.Millisecond) })
+	defer ts.stop()
+	c := ts.dial(t)
+	defer c.conn.Close()
+	var list protocol.S2CRoomList
+	err := c.call(protocol.C2SCmd_RoomList, &protocol.C2SRoomList{}, &list)
+	if e, ok := err.(*protocol.S2CError); !ok || e.Code != protocol.ErrCode_ErrNotLogin {
+		t.Fatalf("got %v, want ErrNotLogin", err)
+	}
+	//ping is accepted before login
+	c.send(protocol.C2SCmd_Ping, &protocol.C2SPing{Time: 1})
+	var pong protocol.S2CPong
+	c.wait(protocol.S2CCmd_Pong, &pong)
+	if pong.Time != 1 {
+		t.Fatalf("got pong %d", pong.Time)
+	}
+	start := time.Now()
+	if !c.closed() {
+		t.Fatal("player not login is not stopped")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("login timeout is not applied")
+	}
+}
+
+func TestHeartbeatEviction(t *testing.T) {
+	ts := startServer(t, func(s *Server) { s.SetHeartbeat(50*time.Millisecond, 2) })
+	defer ts.stop()
+	observer := ts.dial(t)
+	defer observer.conn.Close()
+	observer.login(otherToken)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		//observer keep alive, encoder is only used here from now on
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				observer.enc.Encode(int32(protocol.C2SCmd_Ping), &protocol.C2SPing{})
+			case <-done:
+				return
+			}
+		}
+	}()
+	silent := ts.dial(t)
+	defer silent.conn.Close()
+	id := silent.login(testToken).Id
+	if !silent.closed() {
+		t.Fatal("silent player is not evicted")
+	}
+	//reported like any player leaving
+	for {
+		var presence protocol.S2CPresence
+		observer.wait(protocol.S2CCmd_Presence, &presence)
+		if presence.Player.Id == id && !presence.Online {
+			break
+		}
+	}
+	if _, ok := ts.GetPlayerByID(id); ok {
+		t.Fatal("evicted player is still online")
+	}
+}
+
+//loginIdle login with c and return its player on the server, c read nothing from now on
+func loginIdle(t *testing.T, ts *testServer, c *testConn) *Player {
+	id := c.login(testToken).Id
+	p, ok := ts.GetPlayerByID(id)
+	if !ok {
+		t.Fatal("player is not online")
+	}
+	return p
+}
+
+//fill send queue of p until it is full, the writer is stuck on a connection nobody read
+func fill(p *Player) (int, error) {
+	for i := 0; ; i++ {
+		if err := p.Send(protocol.S2CCmd_Result, &protocol.S2CResult{Context: "x"}); err != nil {
+			return i, err
+		}
+		if i > 100 {
+			return i, nil
+		}
+	}
+}
+
+func TestSendPolicy(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+	tests := []struct {
+		policy  SendPolicy
+		wait    bool
+		stopped bool
+	}{
+		{SendDrop, false, false},
+		{SendBlock, true, false},
+		{SendDisconnect, false, true},
+	}
+	for _, tt := range tests {
+		ts := startServer(t, func(s *Server) { s.SetSendQueue(8, tt.policy, timeout) })
+		c := ts.dial(t)
+		p := loginIdle(t, ts, c)
+		if _, err := fill(p); err != ErrSendQueueFull {
+			t.Fatalf("%s: got %v, want ErrSendQueueFull", tt.policy, err)
+		}
+		start := time.Now()
+		err := p.Send(protocol.S2CCmd_Result, &protocol.S2CResult{})
+		waited := time.Since(start) >= timeout*4/5
+		if tt.stopped {
+			//stopped by the first full queue
+			if !c.closed() {
+				t.Fatalf("%s: player is not stopped", tt.policy)
+			}
+		} else if err != ErrSendQueueFull || waited != tt.wait {
+			t.Fatalf("%s: got %v, waited %v", tt.policy, err, waited)
+		}
+		c.conn.Close()
+		ts.stop()
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	ts := startServer(t, nil)
+	defer ts.stop()
+	c := ts.dial(t)
+	defer c.conn.Close()
+	p := loginIdle(t, ts, c)
+	for i := 0; i < 3; i++ {
+		p.Send(protocol.S2CCmd_Result, &protocol.S2CResult{Context: fmt.Sprint(i)})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- ts.Shutdown(ctx) }()
+	//queued pushes are written before shutdown and close
+	for i := 0; i < 3; i++ {
+		var msg protocol.S2CResult
+		c.wait(protocol.S2CCmd_Result, &msg)
+		if msg.Context != fmt.Sprint(i) {
+			t.Fatalf("got %s, want %d", msg.Context, i)
+		}
+	}
+	var shutdown protocol.S2CShutdown
+	c.wait(protocol.S2CCmd_Shutdown, &shutdown)
+	if !c.closed() {
+		t.Fatal("connection is not closed")
+	}
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	//nothing is accepted after shutdown
+	if _, err := ts.l.Dial(); err == nil {
+		t.Fatal("listener is not closed")
+	}
+}
+
+func TestShutdownForceClose(t *testing.T) {
+	ts := startServer(t, nil)
+	defer ts.stop()
+	c := ts.dial(t)
+	defer c.conn.Close()
+	p := loginIdle(t, ts, c)
+	fill(p)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	//the queue never drain since c read nothing
+	if err := ts.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+	if !c.closed() {
+		t.Fatal("connection is not force closed")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+func (s *Server) isClosing() bool {
+	s.closeMutex.RLock()
+	defer s.closeMutex.RUnlock()
+	return s.closing
+}
+
+func (s *Server) addListener(l net.Listener) bool {
+	s.closeMutex.Lock()
+	defer s.closeMutex.Unlock()
+	if s.closing {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) delListener(l net.Listener) {
+	s.closeMutex.Lock()
+	defer s.closeMutex.Unlock()
+	delete(s.listeners, l)
+	l.Close()
+}
+
+//addHandling refuse new package once shutdown begin
+func (s *Server) addHandling() bool {
+	s.closeMutex.RLock()
+	defer s.closeMutex.RUnlock()
+	if s.closing {
+		return false
+	}
+	s.handling.Add(1)
+	return true
+}
+
+//addPlaying refuse new player once shutdown begin
+func (s *Server) addPlaying() bool {
+	s.closeMutex.RLock()
+	defer s.closeMutex.RUnlock()
+	if s.closing {
+		return false
+	}
+	s.playing.Add(1)
+	return true
+}
+
+func wait(ctx context.Context, wg interface{ Wait() }) error {
+	ch := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//SetShutdownTimeout how long Run wait players drain after stop
+func (s *Server) SetShutdownTimeout(timeout time.Duration) {
+	s.shutdownTimeout = timeout
+}
+
+//Shutdown close every listener and tell players server is going down
+//wait in-flight handles and send queues drain until ctx done, then force close the rest
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeMutex.Lock()
+	if s.closing {
+		s.closeMutex.Unlock()
+		return nil
+	}
+	s.closing = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	s.closeMutex.Unlock()
+
+	players := s.getPlayerList()
+	for _, p := range players {
+		p.Send(protocol.S2CCmd_Shutdown, &protocol.S2CShutdown{
+			Reason: "server shutdown",
+		})
+	}
+	err := wait(ctx, &s.handling)
+	if err == nil {
+		for _, p := range players {
+			close(p.chClosing)
+		}
+		err = wait(ctx, &s.playing)
+	}
+	if err != nil {
+		for _, p := range s.getPlayerList() {
+			log.Printf("player(%d) force close\n", p.index)
+			p.conn.Close()
+			p.Quit(fmt.Errorf("player(%d) force close", p.index))
+		}
+	}
+	return err
+}
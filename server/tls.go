@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+//LoadTLSConfig server certificate and key from files
+//if clientCA is set, client certificate signed by it is verified and its CN can be used to login
+func LoadTLSConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCA == "" {
+		return config, nil
+	}
+	pem, err := ioutil.ReadFile(clientCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificate", clientCA)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	return config, nil
+}
+
+//SetTLS serve ListenTCP and ListenWebSocket with config, nil is plain tcp
+//unix socket and listeners passed to Serve are not affected
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tlsConfig = config
+}
+
+//certName CN of verified client certificate, empty if none
+func (p *Player) certName() string {
+	t, ok := p.conn.(tlsTransport)
+	if !ok {
+		return ""
+	}
+	state := t.TLSState()
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/xlplbo/go_protobuf_test/protocol"
+)
+
+//Transport connection of a player, handles do not care which one it is
+type Transport interface {
+	//ReadPackage block until a whole package arrive
+	//protocol.ErrFrameTooLarge if package body exceed max frame size
+	ReadPackage() (*protocol.Package, error)
+	//WriteFrame write one frame packed by protocol.PackRequest
+	WriteFrame(frame []byte) error
+	SetReadDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+//tlsTransport implemented by transport which may run over tls
+type tlsTransport interface {
+	//TLSState nil if not tls
+	TLSState() *tls.ConnectionState
+}
+
+//streamTransport length prefixed frames over tcp or tls stream
+type streamTransport struct {
+	net.Conn
+	dec *protocol.Decoder
+}
+
+func newStreamTransport(conn net.Conn, maxFrameSize int) *streamTransport {
+	dec := protocol.NewDecoder(conn)
+	dec.SetMaxFrameSize(maxFrameSize)
+	return &streamTransport{
+		Conn: conn,
+		dec:  dec,
+	}
+}
+
+//ReadPackage implement Transport
+func (t *streamTransport) ReadPackage() (*protocol.Package, error) {
+	return t.dec.Decode()
+}
+
+//WriteFrame implement Transport
+func (t *streamTransport) WriteFrame(frame []byte) error {
+	_, err := t.Write(frame)
+	return err
+}
+
+//TLSState implement tlsTransport
+func (t *streamTransport) TLSState() *tls.ConnectionState {
+	conn, ok := t.Conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := conn.ConnectionState()
+	return &state
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xlplbo/go_protobuf_test/protocol"
+	"golang.org/x/net/websocket"
+)
+
+//wsTransport one binary message is one protocol.Package, without the length head
+type wsTransport struct {
+	*websocket.Conn
+	once sync.Once
+	done chan struct{}
+	//certLogin client certificate can login, false for a page of an origin not allowed
+	certLogin bool
+}
+
+//wsAddr remote address of the http request
+type wsAddr string
+
+func (a wsAddr) Network() string { return "websocket" }
+func (a wsAddr) String() string  { return string(a) }
+
+//RemoteAddr implement Transport, websocket.Conn return origin instead of peer address
+func (t *wsTransport) RemoteAddr() net.Addr {
+	return wsAddr(t.Request().RemoteAddr)
+}
+
+//ReadPackage implement Transport
+func (t *wsTransport) ReadPackage() (*protocol.Package, error) {
+	var buff []byte
+	if err := websocket.Message.Receive(t.Conn, &buff); err != nil {
+		if err == websocket.ErrFrameTooLarge {
+			return nil, protocol.ErrFrameTooLarge
+		}
+		return nil, err
+	}
+	var pkg protocol.Package
+	if err := proto.Unmarshal(buff, &pkg); err != nil {
+		return nil, protocol.ErrCorruptFrame
+	}
+	return &pkg, nil
+}
+
+//WriteFrame implement Transport
+func (t *wsTransport) WriteFrame(frame []byte) error {
+	return websocket.Message.Send(t.Conn, frame[protocol.HeadSize:])
+}
+
+//Close implement Transport, release the http handler
+func (t *wsTransport) Close() error {
+	err := t.Conn.Close()
+	t.once.Do(func() { close(t.done) })
+	return err
+}
+
+//TLSState implement tlsTransport
+//a browser present the user certificate to any page, so it is hidden unless the origin is allowed
+func (t *wsTransport) TLSState() *tls.ConnectionState {
+	if !t.certLogin {
+		return nil
+	}
+	return t.Request().TLS
+}
+
+//SetWebSocketOrigins pages allowed to open websocket, such as https://chat.example.com
+//empty allow any origin but only clients without Origin, not browsers, can login by certificate
+//call before ListenWebSocket
+func (s *Server) SetWebSocketOrigins(origins []string) {
+	s.wsOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		s.wsOrigins[origin] = true
+	}
+}
+
+//checkOrigin refuse a page not allowed, return whether the client certificate can login
+func (s *Server) checkOrigin(req *http.Request) (bool, error) {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true, nil
+	}
+	if s.wsOrigins[origin] {
+		return true, nil
+	}
+	if len(s.wsOrigins) > 0 {
+		return false, fmt.Errorf("origin %s is not allowed", origin)
+	}
+	return false, nil
+}
+
+//ListenWebSocket accept websocket at path, only call func use go routine
+//origin is checked by SetWebSocketOrigins, players still have to login
+func (s *Server) ListenWebSocket(laddr, path string) {
+	l, err := net.Listen("tcp", laddr)
+	if err != nil {
+		s.stop(err)
+		return
+	}
+	if s.tlsConfig != nil {
+		l = tls.NewListener(l, s.tlsConfig)
+	}
+	if !s.addListener(l) {
+		l.Close()
+		return
+	}
+	defer s.delListener(l)
+	mux := http.NewServeMux()
+	mux.Handle(path, websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			_, err := s.checkOrigin(req)
+			return err
+		},
+		Handler: func(conn *websocket.Conn) {
+			//a message carry no length head
+			conn.MaxPayloadBytes = s.maxFrameSize
+			certLogin, _ := s.checkOrigin(conn.Request())
+			t := &wsTransport{
+				Conn:      conn,
+				done:      make(chan struct{}),
+				certLogin: certLogin,
+			}
+			s.chConn <- t
+			//conn is closed when handler return
+			<-t.done
+		},
+	})
+	log.Printf("listen websocket at %s%s\n", l.Addr().String(), path)
+	if err := http.Serve(l, mux); err != nil && !s.isClosing() {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowed   []string
+		origin    string
+		certLogin bool
+		refused   bool
+	}{
+		{"no origin", nil, "", true, false},
+		{"any origin", nil, "https://evil.example.com", false, false},
+		{"allowed", []string{"https://chat.example.com"}, "https://chat.example.com", true, false},
+		{"not allowed", []string{"https://chat.example.com"}, "https://evil.example.com", false, true},
+		{"no origin with allowlist", []string{"https://chat.example.com"}, "", true, false},
+	}
+	for _, tt := range tests {
+		s := NewServer()
+		s.SetWebSocketOrigins(tt.allowed)
+		req, _ := http.NewRequest("GET", "ws://localhost/ws", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		certLogin, err := s.checkOrigin(req)
+		if certLogin != tt.certLogin || (err != nil) != tt.refused {
+			t.Errorf("%s: got %v, %v", tt.name, certLogin, err)
+		}
+	}
+}